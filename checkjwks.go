@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+)
+
+const checkJWKSCommand = "check-jwks"
+
+// checkJWKS compares the issuer documents served by the cluster with the
+// published ones, republishing them through `pulumi up` on request so the
+// stack state keeps tracking the published objects
+func checkJWKS(args []string) error {
+	flags := flag.NewFlagSet(checkJWKSCommand, flag.ContinueOnError)
+	issuerURL := flags.String("issuer", "", "issuer url the documents are published at")
+	kubeconfig := flags.String("kubeconfig", defaultKubeconfig(), "kubeconfig of the cluster")
+	kubeContext := flags.String("context", "", "kubeconfig context, the current context when empty")
+	sync := flags.Bool("sync", false, "republish the documents served by the cluster when they drifted")
+	stackName := flags.String("stack", "", "stack to update when syncing")
+	workDir := flags.String("dir", ".", "pulumi project directory used when syncing")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *issuerURL == "" {
+		return errors.New("-issuer must be set")
+	}
+	if *sync && *stackName == "" {
+		return errors.New("-sync requires -stack")
+	}
+
+	kubeconfigData, err := os.ReadFile(*kubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the kubeconfig")
+	}
+
+	drift, err := issuerDrift(*issuerURL, string(kubeconfigData), *kubeContext)
+	if err != nil {
+		return err
+	}
+	if drift.Empty() {
+		fmt.Println("published documents match the cluster")
+		return nil
+	}
+	fmt.Fprintln(os.Stderr, drift)
+	if !*sync {
+		return errors.New("published documents drifted from the cluster, rerun with -sync to republish them")
+	}
+
+	if err := republish(*stackName, *workDir); err != nil {
+		return err
+	}
+	if drift, err = issuerDrift(*issuerURL, string(kubeconfigData), *kubeContext); err != nil {
+		return err
+	}
+	if !drift.Empty() {
+		fmt.Fprintln(os.Stderr, drift)
+		return errors.New("published documents still drift from the cluster after republishing")
+	}
+	fmt.Println("published documents republished")
+	return nil
+}
+
+func issuerDrift(issuerURL, kubeconfig, kubeContext string) (oidc.Drift, error) {
+	served, err := cluster.GetOIDCConfig(kubeconfig, kubeContext)
+	if err != nil {
+		return oidc.Drift{}, err
+	}
+	published, err := oidc.FetchDocuments(&http.Client{Timeout: 30 * time.Second}, issuerURL)
+	if err != nil {
+		return oidc.Drift{}, err
+	}
+	return oidc.CompareDocuments(*published, *served), nil
+}
+
+// republish runs `pulumi refresh` and `pulumi up` on the stack, the issuer
+// host publishes the documents the cluster serves
+func republish(stackName, workDir string) error {
+	ctx := context.Background()
+	stack, err := auto.SelectStackLocalSource(ctx, stackName, workDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to select stack %s", stackName)
+	}
+	// the refresh reads the documents the cluster serves now, the update
+	// publishes them
+	if _, err := stack.Refresh(ctx, optrefresh.ProgressStreams(os.Stdout)); err != nil {
+		return errors.Wrap(err, "failed to refresh the cluster documents")
+	}
+	_, err = stack.Up(ctx, optup.Message("republish issuer documents"), optup.ProgressStreams(os.Stdout))
+	return errors.Wrap(err, "failed to republish the issuer documents")
+}
+
+func defaultKubeconfig() string {
+	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
+		return filepath.SplitList(kubeconfig)[0]
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "config")
+}
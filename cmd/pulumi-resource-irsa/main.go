@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/frezbo/irsa-anywhere/pkg/provider"
+)
+
+// version is set at build time with `-ldflags "-X main.version=..."`
+var version = "0.0.1"
+
+func main() {
+	engineAddress := engineAddress(os.Args[1:])
+	if engineAddress == "" {
+		fmt.Fprintln(os.Stderr, "missing the engine address, the provider is started by the pulumi engine")
+		os.Exit(1)
+	}
+	if err := provider.Serve(version, engineAddress); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// engineAddress is the first argument the engine starts the plugin with,
+// after the logging and tracing flags
+func engineAddress(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--tracing":
+			// the tracing endpoint is passed as a separate argument
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			return args[i]
+		}
+	}
+	return ""
+}
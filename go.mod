@@ -5,13 +5,18 @@ go 1.16
 require (
 	github.com/frezbo/pulumi-provider-kind/sdk/v3 v3.0.0-20211105090606-cde52303c7d8
 	github.com/pkg/errors v0.9.1
-	github.com/pulumi/pulumi-aws/sdk/v4 v4.38.1
+	github.com/pulumi/pulumi-aws/sdk/v5 v5.14.0
+	github.com/pulumi/pulumi-command/sdk v0.5.1
 	github.com/pulumi/pulumi-kubernetes/sdk/v3 v3.21.0
 	github.com/pulumi/pulumi-tls/sdk/v4 v4.6.0
-	github.com/pulumi/pulumi/sdk/v3 v3.38.0
+	github.com/pulumi/pulumi/sdk/v3 v3.39.3
+	google.golang.org/grpc v1.47.0
+	google.golang.org/protobuf v1.28.0
+	k8s.io/api v0.22.3
 	k8s.io/apimachinery v0.22.3
+	k8s.io/client-go v0.22.3
 	k8s.io/kubernetes v1.25.0
-	sigs.k8s.io/kind v0.14.0
+	sigs.k8s.io/kind v0.23.0
 )
 
 replace (
@@ -1,16 +1,149 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/cluster/existing"
+	"github.com/frezbo/irsa-anywhere/pkg/cluster/k3d"
 	"github.com/frezbo/irsa-anywhere/pkg/cluster/kind"
+	"github.com/frezbo/irsa-anywhere/pkg/issuer/bucket"
+	"github.com/frezbo/irsa-anywhere/pkg/issuer/cloudfront"
+	"github.com/frezbo/irsa-anywhere/pkg/issuer/directory"
+	"github.com/pkg/errors"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	pulumiconfig "github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
 )
 
+var subcommands = map[string]func(args []string) error{
+	verifyTokenCommand: verifyToken,
+	checkJWKSCommand:   checkJWKS,
+}
+
 func main() {
+	// pulumi runs the program without arguments
+	if len(os.Args) > 1 {
+		if command, ok := subcommands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	pulumi.Run(func(ctx *pulumi.Context) error {
-		kindProvider := kind.NewKindConfig(ctx, "kind-aws")
-		if _, err := kindProvider.Create(); err != nil {
+		name, backend, err := clusterBackend(ctx)
+		if err != nil {
+			return err
+		}
+		audiences, err := clusterAudiences(ctx)
+		if err != nil {
 			return err
 		}
+		host, err := issuerHost(ctx, name)
+		if err != nil {
+			return err
+		}
+		clusterConfig := cluster.NewClusterConfig(ctx, name, backend, host, audiences)
+		outputs, err := clusterConfig.Create()
+		if err != nil {
+			return err
+		}
+		exportOutputs(ctx, outputs)
 		return nil
 	})
 }
+
+// exportOutputs exports what application stacks read through a StackReference
+func exportOutputs(ctx *pulumi.Context, outputs *cluster.Outputs) {
+	ctx.Export(cluster.IssuerURLOutput, outputs.Cluster.IssuerURL)
+	ctx.Export(cluster.OIDCProviderARNOutput, outputs.Cluster.OIDCProviderARN)
+	ctx.Export(cluster.AudienceOutput, outputs.Webhook.Audience)
+	ctx.Export(cluster.AnnotationPrefixOutput, outputs.Webhook.AnnotationPrefix)
+	ctx.Export(cluster.WebhookNamespaceOutput, outputs.Webhook.Namespace)
+	ctx.Export(cluster.ClusterNameOutput, outputs.Cluster.ClusterName)
+	ctx.Export(cluster.KubeconfigOutput, outputs.Cluster.Kubeconfig)
+	if outputs.IssuerBucket != nil {
+		ctx.Export(cluster.IssuerBucketOutput, outputs.IssuerBucket)
+	}
+}
+
+// clusterBackend picks the cluster backend from the `clusterBackend`
+// stack config, defaulting to kind
+func clusterBackend(ctx *pulumi.Context) (string, cluster.ClusterBackend, error) {
+	cfg := pulumiconfig.New(ctx, "")
+	switch backend := cfg.Get("clusterBackend"); backend {
+	case "", "kind":
+		topology := kind.DefaultTopology()
+		if err := cfg.GetObject("kindTopology", topology); err != nil {
+			return "", nil, errors.Wrap(err, "failed to parse kindTopology")
+		}
+		nodeImage := &kind.NodeImage{
+			Image:             cfg.Get("kindNodeImage"),
+			KubernetesVersion: cfg.Get("kubernetesVersion"),
+		}
+		signingKey, err := kindSigningKey(cfg)
+		if err != nil {
+			return "", nil, err
+		}
+		return "kind-aws", kind.NewKindConfig(ctx, "kind-aws", topology, nodeImage, signingKey), nil
+	case "k3d":
+		return "k3d-aws", k3d.NewK3dConfig(ctx, "k3d-aws"), nil
+	case "existing":
+		return "existing-aws", existing.NewExistingConfig(ctx, "existing-aws", cfg.RequireSecret("kubeconfig"), cfg.Get("kubeconfigContext")), nil
+	default:
+		return "", nil, errors.Errorf("unsupported cluster backend: %s", backend)
+	}
+}
+
+// issuerHost picks where the issuer documents are hosted from the
+// `issuerHost` stack config, defaulting to an S3 bucket
+func issuerHost(ctx *pulumi.Context, name string) (cluster.IssuerHost, error) {
+	cfg := pulumiconfig.New(ctx, "")
+	issuerDomain := cfg.Get("issuerDomain")
+	switch host := cfg.Get("issuerHost"); host {
+	case "", "s3":
+		if issuerDomain != "" {
+			return nil, errors.New("issuerDomain needs the cloudfront issuer host, S3 does not serve a custom domain over HTTPS")
+		}
+		return bucket.NewBucketConfig(ctx, name), nil
+	case "cloudfront":
+		var domain *cloudfront.Domain
+		if issuerDomain != "" {
+			domain = &cloudfront.Domain{
+				Name: issuerDomain,
+				Zone: cfg.Get("issuerZone"),
+			}
+		}
+		return cloudfront.NewCloudFrontConfig(ctx, name, domain), nil
+	case "directory":
+		dir := cfg.Get("issuerDirectory")
+		if dir == "" {
+			dir = "issuer"
+		}
+		return directory.NewDirectoryConfig(ctx, name, dir, cfg.Require("issuerURL")), nil
+	default:
+		return nil, errors.Errorf("unsupported issuer host: %s", host)
+	}
+}
+
+// clusterAudiences reads the `audiences` list, fed to the apiserver and the
+// AWS IAM OIDC provider, and the `webhookAudience` the webhook requests
+// tokens for
+func clusterAudiences(ctx *pulumi.Context) (*cluster.Audiences, error) {
+	cfg := pulumiconfig.New(ctx, "")
+	var audiences []string
+	if err := cfg.GetObject("audiences", &audiences); err != nil {
+		return nil, errors.Wrap(err, "failed to parse audiences")
+	}
+	return cluster.NewAudiences(audiences, cfg.Get("webhookAudience"))
+}
+
+// kindSigningKey reads the stack managed service account signing key config,
+// returning nil when the key generated by kubeadm should be used
+func kindSigningKey(cfg *pulumiconfig.Config) (*kind.SigningKey, error) {
+	signingKey, err := kind.NewSigningKey(cfg.Get("serviceAccountKeyAlgorithm"), cfg.GetInt("serviceAccountKeyGeneration"), cfg.Get("serviceAccountMaxTokenLifetime"))
+	return signingKey, errors.Wrap(err, "failed to parse the serviceAccountKey stack config")
+}
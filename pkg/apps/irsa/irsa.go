@@ -18,14 +18,21 @@ import (
 
 const (
 	awsPodIdentityVersion = "ed8c41f"
+
+	// Namespace is where the pod identity webhook runs
+	Namespace = "irsa-system"
+	// AnnotationPrefix prefixes the service account annotations the webhook
+	// reads, e.g. `eks.amazonaws.com/role-arn`
+	AnnotationPrefix = "eks.amazonaws.com"
 )
 
-func NewIRSAConfig(ctx *pulumi.Context, name string, kubeconfig pulumi.StringInput, component *component.DynamicComponent) resource.Resource {
+func NewIRSAConfig(ctx *pulumi.Context, name string, kubeconfig pulumi.StringInput, tokenAudience string, parent *component.Webhook) resource.Resource {
 	return &irsaConfig{
 		pulumiContext: ctx,
 		name:          name,
 		kubeconfig:    kubeconfig,
-		parent:        component,
+		tokenAudience: tokenAudience,
+		parent:        parent,
 	}
 }
 
@@ -41,7 +48,7 @@ func (c *irsaConfig) Create() (pulumi.Resource, error) {
 	resourceLabels := commonLabels(c.name)
 	ns, err := corev1.NewNamespace(c.pulumiContext, c.name, &corev1.NamespaceArgs{
 		Metadata: metav1.ObjectMetaArgs{
-			Name:   pulumi.String("irsa-system"),
+			Name:   pulumi.String(Namespace),
 			Labels: resourceLabels,
 		},
 	}, resourceOpts...)
@@ -212,10 +219,8 @@ func (c *irsaConfig) Create() (pulumi.Resource, error) {
 		},
 		KeyAlgorithm:  pulumi.String("ECDSA"),
 		PrivateKeyPem: privKey.PrivateKeyPem,
-		Subjects: tls.SelfSignedCertSubjectArray{
-			tls.SelfSignedCertSubjectArgs{
-				CommonName: pulumi.String("pod-identity-webhook"),
-			},
+		Subject: tls.SelfSignedCertSubjectArgs{
+			CommonName: pulumi.String("pod-identity-webhook"),
 		},
 		ValidityPeriodHours: pulumi.Int(72),
 	}, pulumi.Parent(c.parent))
@@ -286,8 +291,8 @@ func (c *irsaConfig) Create() (pulumi.Resource, error) {
 								pulumi.Sprintf("--namespace=%s", resourceNamespace),
 								pulumi.String("--service-name=pod-identity-webhook"),
 								pulumi.Sprintf("--tls-secret=%s", secret.Metadata.Name().Elem()),
-								pulumi.String("--annotation-prefix=eks.amazonaws.com"),
-								pulumi.String("--token-audience=sts.amazonaws.com"),
+								pulumi.String(fmt.Sprintf("--annotation-prefix=%s", AnnotationPrefix)),
+								pulumi.Sprintf("--token-audience=%s", c.tokenAudience),
 								pulumi.String("--logtostderr"),
 							},
 							Image:           pulumi.Sprintf("amazon/amazon-eks-pod-identity-webhook:%s", awsPodIdentityVersion),
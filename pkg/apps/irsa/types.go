@@ -9,5 +9,6 @@ type irsaConfig struct {
 	pulumiContext *pulumi.Context
 	name          string
 	kubeconfig    pulumi.StringInput
-	parent        *component.DynamicComponent
+	tokenAudience string
+	parent        *component.Webhook
 }
@@ -5,9 +5,10 @@ import (
 
 	awsmeta "github.com/frezbo/irsa-anywhere/pkg/aws/meta"
 	"github.com/frezbo/irsa-anywhere/pkg/component"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
 	"github.com/frezbo/irsa-anywhere/pkg/resource"
-	"github.com/pulumi/pulumi-aws/sdk/v4/go/aws/iam"
-	"github.com/pulumi/pulumi-aws/sdk/v4/go/aws/s3"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes"
 	corev1 "github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes/core/v1"
 	v1 "github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes/meta/v1"
@@ -15,17 +16,23 @@ import (
 )
 
 const (
-	appName = "sampleapp"
+	// Name is the name the sampleapp is created with by the program
+	Name = "sampleapp"
+	// Namespace is where the sampleapp runs
+	Namespace = "irsa-test"
+	// ServiceAccountName is the service account assuming the sampleapp role
+	ServiceAccountName = "irsa-test"
 )
 
-func NewSampleAppConfig(ctx *pulumi.Context, oidcEndpoint, oidcArn, kubeconfig pulumi.StringInput, component *component.DynamicComponent, deps []pulumi.Resource) resource.Resource {
+func NewSampleAppConfig(ctx *pulumi.Context, name string, oidcEndpoint, oidcArn, kubeconfig pulumi.StringInput, audience string, parent *component.WorkloadRole, deps []pulumi.Resource) resource.Resource {
 	return &sampleAppConfig{
 		pulumiContext: ctx,
-		name:          appName,
+		name:          name,
 		oidcEndpoint:  oidcEndpoint,
 		oidcArn:       oidcArn,
 		kubeconfig:    kubeconfig,
-		parent:        component,
+		audience:      audience,
+		parent:        parent,
 		dependencies:  deps,
 	}
 }
@@ -107,15 +114,7 @@ func (c *sampleAppConfig) Create() (pulumi.Resource, error) {
 							Identifiers: []string{oidcArn},
 						},
 					},
-					Conditions: []iam.GetPolicyDocumentStatementCondition{
-						{
-							Test:     "StringEquals",
-							Variable: fmt.Sprintf("%s:sub", oidcEndpoint),
-							Values: []string{
-								fmt.Sprintf("system:serviceaccount:%s:irsa-test", namespace),
-							},
-						},
-					},
+					Conditions: assumeRoleConditions(oidcEndpoint, namespace, c.audience),
 				},
 			},
 		}, pulumi.Parent(c.parent))
@@ -141,11 +140,11 @@ func (c *sampleAppConfig) Create() (pulumi.Resource, error) {
 			Labels: resourceLabels,
 			Annotations: pulumi.StringMap{
 				"eks.amazonaws.com/role-arn":               role.Arn,
-				"eks.amazonaws.com/audience":               pulumi.String("sts.amazonaws.com"),
+				"eks.amazonaws.com/audience":               pulumi.String(c.audience),
 				"eks.amazonaws.com/sts-regional-endpoints": pulumi.String("true"),
 				"eks.amazonaws.com/token-expiration":       pulumi.String("86400"),
 			},
-			Name:      pulumi.String("irsa-test"),
+			Name:      pulumi.String(ServiceAccountName),
 			Namespace: ns.Metadata.Name().Elem(),
 		},
 	}, nsk8sResourceOpts...)
@@ -164,7 +163,7 @@ func (c *sampleAppConfig) Create() (pulumi.Resource, error) {
 		Acl:     s3.CannedAclPublicRead,
 		Bucket:  bucket.ID(),
 		Content: pulumi.String("Hey,\n\nthis means your kind cluster is successfully able to talk to AWS\nwithout any long lived credentials, using aws pod identity webhook.\n\nHappy hacking\n"),
-		Key:     pulumi.String(appName),
+		Key:     pulumi.String(Name),
 		Tags:    commonAwsResourceTags,
 	}, pulumi.Parent(bucket))
 	if err != nil {
@@ -257,9 +256,33 @@ func (c *sampleAppConfig) Create() (pulumi.Resource, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := c.parent.RegisterOutputs(c.pulumiContext, role.Arn); err != nil {
+		return nil, err
+	}
 	return pod, nil
 }
 
+// assumeRoleConditions only lets tokens issued to the sampleapp service
+// account for the webhook audience assume the role
+func assumeRoleConditions(oidcEndpoint, namespace, audience string) []iam.GetPolicyDocumentStatementCondition {
+	return []iam.GetPolicyDocumentStatementCondition{
+		{
+			Test:     "StringEquals",
+			Variable: fmt.Sprintf("%s:sub", oidcEndpoint),
+			Values: []string{
+				oidc.ServiceAccountSubject(namespace, ServiceAccountName),
+			},
+		},
+		{
+			Test:     "StringEquals",
+			Variable: fmt.Sprintf("%s:aud", oidcEndpoint),
+			Values: []string{
+				audience,
+			},
+		},
+	}
+}
+
 func commonLabels(instance string) pulumi.StringMap {
 	// not setting the `app.kubernetes.io/managed-by`
 	// label since pulumi already sets that
@@ -0,0 +1,65 @@
+package sampleapp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc/oidctest"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+)
+
+func TestAssumeRoleConditions(t *testing.T) {
+	expected := []iam.GetPolicyDocumentStatementCondition{
+		{
+			Test:     "StringEquals",
+			Variable: "oidc.example.com/kind-aws:sub",
+			Values:   []string{"system:serviceaccount:irsa-test:" + ServiceAccountName},
+		},
+		{
+			Test:     "StringEquals",
+			Variable: "oidc.example.com/kind-aws:aud",
+			Values:   []string{"sts.amazonaws.com"},
+		},
+	}
+	if actual := assumeRoleConditions("oidc.example.com/kind-aws", "irsa-test", "sts.amazonaws.com"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %+v\n, got: %+v\n", expected, actual)
+	}
+}
+
+func TestAssumeRoleConditionsMatchTokenClaims(t *testing.T) {
+	issuer, err := oidctest.NewIssuer(oidctest.AlgorithmRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+	documents, err := issuer.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := issuer.Mint(issuer.Claims("irsa-test", ServiceAccountName, "sts.amazonaws.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := oidc.VerifyToken(token, documents.JWKS, oidc.TokenExpectations{
+		Issuer:   documents.Discovery.Issuer,
+		Audience: "sts.amazonaws.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the values STS compares the token's claims with
+	expected := map[string][]string{
+		issuer.Host() + ":sub": {claims.Subject},
+		issuer.Host() + ":aud": claims.Audience,
+	}
+	actual := map[string][]string{}
+	for _, condition := range assumeRoleConditions(issuer.Host(), "irsa-test", "sts.amazonaws.com") {
+		actual[condition.Variable] = condition.Values
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %v\n, got: %v\n", expected, actual)
+	}
+}
@@ -11,6 +11,7 @@ type sampleAppConfig struct {
 	oidcEndpoint  pulumi.StringInput
 	oidcArn       pulumi.StringInput
 	kubeconfig    pulumi.StringInput
-	parent        *component.DynamicComponent
+	audience      string
+	parent        *component.WorkloadRole
 	dependencies  []pulumi.Resource
 }
@@ -2,7 +2,7 @@ package meta
 
 import (
 	"github.com/frezbo/irsa-anywhere/pkg/common/meta"
-	"github.com/pulumi/pulumi-aws/sdk/v4/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Audiences are the token audiences accepted for the issuer, they are all
+// derived from a single list so the apiserver, the AWS IAM OIDC provider and
+// the pod identity webhook stay consistent
+type Audiences struct {
+	// APIServer is passed to the apiserver `--api-audiences` flag
+	APIServer []string
+	// Provider are the client ids of the AWS IAM OIDC provider
+	Provider []string
+	// Webhook is the audience the pod identity webhook requests tokens for
+	Webhook string
+}
+
+// NewAudiences derives the audiences from the audiences list, defaulting to
+// `STSAudience`, the apiserver always accepts the in cluster audience as well
+func NewAudiences(audiences []string, webhookAudience string) (*Audiences, error) {
+	if len(audiences) == 0 {
+		audiences = []string{STSAudience}
+	}
+	if webhookAudience == "" {
+		webhookAudience = STSAudience
+	}
+	a := &Audiences{
+		APIServer: appendUnique([]string{InClusterAudience}, audiences...),
+		Provider:  appendUnique(nil, audiences...),
+		Webhook:   webhookAudience,
+	}
+	return a, a.Validate()
+}
+
+// Validate rejects audiences that would make the tokens injected by the
+// webhook unusable
+func (a *Audiences) Validate() error {
+	for _, audience := range append(append([]string{}, a.APIServer...), a.Provider...) {
+		if audience == "" {
+			return errors.New("audiences must not be empty")
+		}
+		// the apiserver flag is a comma separated list
+		if strings.Contains(audience, ",") {
+			return errors.Errorf("audience %q must not contain a comma", audience)
+		}
+	}
+	if !contains(a.APIServer, a.Webhook) {
+		return errors.Errorf("webhook audience %q is missing from the apiserver audiences %q, the apiserver would refuse to issue its tokens", a.Webhook, a.APIServer)
+	}
+	if !contains(a.Provider, a.Webhook) {
+		return errors.Errorf("webhook audience %q is missing from the AWS IAM OIDC provider client ids %q, AWS would reject its tokens", a.Webhook, a.Provider)
+	}
+	return nil
+}
+
+func appendUnique(values []string, more ...string) []string {
+	for _, value := range more {
+		if !contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewAudiences(t *testing.T) {
+	expected := &Audiences{
+		APIServer: []string{InClusterAudience, STSAudience},
+		Provider:  []string{STSAudience},
+		Webhook:   STSAudience,
+	}
+	if actual, err := NewAudiences(nil, ""); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %+v\n, got: %+v\n", expected, actual)
+	}
+
+	expected = &Audiences{
+		APIServer: []string{InClusterAudience, STSAudience, "vault"},
+		Provider:  []string{STSAudience, "vault"},
+		Webhook:   "vault",
+	}
+	if actual, err := NewAudiences([]string{STSAudience, "vault", "vault"}, "vault"); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %+v\n, got: %+v\n", expected, actual)
+	}
+}
+
+func TestAudiencesValidate(t *testing.T) {
+	tests := []*Audiences{
+		{
+			APIServer: []string{InClusterAudience},
+			Provider:  []string{STSAudience},
+			Webhook:   STSAudience,
+		},
+		{
+			APIServer: []string{InClusterAudience, STSAudience},
+			Provider:  []string{"vault"},
+			Webhook:   STSAudience,
+		},
+		{
+			APIServer: []string{InClusterAudience, "sts.amazonaws.com,vault"},
+			Provider:  []string{STSAudience},
+			Webhook:   STSAudience,
+		},
+	}
+	for _, test := range tests {
+		if err := test.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", test)
+		}
+	}
+
+	if _, err := NewAudiences([]string{"vault"}, ""); err == nil {
+		t.Error("expected the default webhook audience to be required in the audiences")
+	}
+}
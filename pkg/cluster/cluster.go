@@ -0,0 +1,198 @@
+package cluster
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/apps/irsa"
+	"github.com/frezbo/irsa-anywhere/pkg/apps/sampleapp"
+	awsmeta "github.com/frezbo/irsa-anywhere/pkg/aws/meta"
+	"github.com/frezbo/irsa-anywhere/pkg/component"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	pulumiconfig "github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
+)
+
+const (
+	InClusterAudience = "https://kubernetes.default.svc.cluster.local"
+	STSAudience       = "sts.amazonaws.com"
+)
+
+// NewClusterConfig wires the issuer host, the IAM OIDC provider and the pod
+// identity webhook around a cluster created by the given backend
+func NewClusterConfig(ctx *pulumi.Context, name string, backend ClusterBackend, issuerHost IssuerHost, audiences *Audiences) ClusterConfig {
+	return &clusterConfig{
+		pulumiContext: ctx,
+		name:          name,
+		backend:       backend,
+		issuerHost:    issuerHost,
+		audiences:     audiences,
+	}
+}
+
+func (c *clusterConfig) Create() (*Outputs, error) {
+	if err := c.audiences.Validate(); err != nil {
+		return nil, err
+	}
+
+	clusterComponent, err := component.NewCluster(c.pulumiContext, c.name, &component.ClusterArgs{
+		Audiences: c.audiences.APIServer,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	issuerComponent, err := component.NewIssuer(c.pulumiContext, fmt.Sprintf("%s-issuer", c.name), &component.IssuerArgs{
+		ClientIDs: c.audiences.Provider,
+	}, clusterComponent)
+	if err != nil {
+		return nil, err
+	}
+
+	commonAwsResourceTags, err := awsmeta.ResourceTags(c.pulumiContext, c.name)
+	if err != nil {
+		return nil, err
+	}
+
+	issuerURL, err := c.issuerHost.Create(issuerComponent)
+	if err != nil {
+		return nil, err
+	}
+
+	caFingerprint, err := c.issuerThumbprint(issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	openIDProvider, err := iam.NewOpenIdConnectProvider(c.pulumiContext, c.name, &iam.OpenIdConnectProviderArgs{
+		Url:             issuerURL,
+		ClientIdLists:   pulumi.ToStringArray(c.audiences.Provider),
+		ThumbprintLists: pulumi.StringArray{caFingerprint},
+		Tags:            commonAwsResourceTags,
+	}, pulumi.Parent(issuerComponent))
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, err := c.backend.Create(&Issuer{
+		URL:       issuerURL,
+		Audiences: c.audiences.APIServer,
+	}, clusterComponent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.publishIssuer(issuerURL, cluster.OIDCConfig); err != nil {
+		return nil, err
+	}
+	if err := issuerComponent.RegisterOutputs(c.pulumiContext, issuerURL, openIDProvider.Arn); err != nil {
+		return nil, err
+	}
+
+	webhook, irsaResource, err := c.installWebhook(cluster.Kubeconfig, clusterComponent)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := pulumiconfig.New(c.pulumiContext, "")
+	if cfg.Get("createSampleApp") == "true" {
+		// IAM condition keys are prefixed with the issuer url without the scheme
+		oidcEndpoint := issuerURL.ApplyT(func(issuerURL string) string {
+			return strings.TrimPrefix(issuerURL, "https://")
+		}).(pulumi.StringOutput)
+		workloadRole, err := component.NewWorkloadRole(c.pulumiContext, sampleapp.Name, &component.WorkloadRoleArgs{
+			Namespace:      sampleapp.Namespace,
+			ServiceAccount: sampleapp.ServiceAccountName,
+		}, clusterComponent)
+		if err != nil {
+			return nil, err
+		}
+		sampleAppConfig := sampleapp.NewSampleAppConfig(c.pulumiContext, sampleapp.Name, oidcEndpoint, openIDProvider.Arn, cluster.Kubeconfig, c.audiences.Webhook, workloadRole, []pulumi.Resource{irsaResource})
+		if _, err := sampleAppConfig.Create(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := clusterComponent.RegisterOutputs(c.pulumiContext, issuerURL, openIDProvider.Arn, cluster.Name, cluster.Kubeconfig); err != nil {
+		return nil, err
+	}
+
+	outputs := &Outputs{
+		Cluster: clusterComponent,
+		Webhook: webhook,
+	}
+	if bucketHost, ok := c.issuerHost.(IssuerBucketHost); ok {
+		outputs.IssuerBucket = bucketHost.Bucket()
+	}
+	return outputs, nil
+}
+
+// issuerThumbprint is the thumbprint of the issuer CA trusted by the AWS IAM
+// OIDC provider, computed from the `issuerCertificateChain` stack config when
+// the chain is pinned, otherwise from the chain the issuer serves
+func (c *clusterConfig) issuerThumbprint(issuerURL pulumi.StringOutput) (pulumi.StringOutput, error) {
+	cfg := pulumiconfig.New(c.pulumiContext, "")
+	if chainPEM := cfg.Get("issuerCertificateChain"); chainPEM != "" {
+		thumbprint, err := oidc.IssuerThumbprint([]byte(chainPEM))
+		if err != nil {
+			return pulumi.StringOutput{}, errors.Wrap(err, "invalid issuerCertificateChain")
+		}
+		return pulumi.String(thumbprint).ToStringOutput(), nil
+	}
+
+	return issuerURL.ApplyT(func(issuerURL string) (string, error) {
+		chainPEM, err := oidc.FetchCertificateChain(&http.Client{Timeout: 30 * time.Second}, issuerURL)
+		if err != nil {
+			return "", err
+		}
+		return oidc.IssuerThumbprint(chainPEM)
+	}).(pulumi.StringOutput), nil
+}
+
+// publishIssuer hands the discovery and JWKS documents served by the cluster
+// to the issuer host once they are validated
+func (c *clusterConfig) publishIssuer(issuerURL pulumi.StringOutput, clusterOIDCConfig OIDCDocumentsOutput) error {
+	oidcConfig := pulumi.All(issuerURL, clusterOIDCConfig).ApplyT(func(args []interface{}) (oidc.Documents, error) {
+		issuerURL := args[0].(string)
+		oidcConfig := args[1].(oidc.Documents)
+
+		return oidcConfig, oidcConfig.Validate(issuerURL)
+	}).(OIDCDocumentsOutput)
+
+	return c.issuerHost.Publish(oidcConfig)
+}
+
+// installWebhook deploys the pod identity webhook into the cluster
+func (c *clusterConfig) installWebhook(kubeconfig pulumi.StringInput, cluster *component.Cluster) (*component.Webhook, pulumi.Resource, error) {
+	webhook, err := component.NewWebhook(c.pulumiContext, fmt.Sprintf("%s-webhook", c.name), &component.WebhookArgs{
+		Namespace:        irsa.Namespace,
+		AnnotationPrefix: irsa.AnnotationPrefix,
+		Audience:         c.audiences.Webhook,
+	}, cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	irsaApp := irsa.NewIRSAConfig(c.pulumiContext, c.name, kubeconfig, c.audiences.Webhook, webhook)
+	irsaResource, err := irsaApp.Create()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := webhook.RegisterOutputs(c.pulumiContext); err != nil {
+		return nil, nil, err
+	}
+	return webhook, irsaResource, nil
+}
+
+// APIServerIssuerArgs returns the kube-apiserver flags needed to issue
+// service account tokens for the given audiences and issuer url
+func APIServerIssuerArgs(issuerURL string, audiences []string) map[string]string {
+	return map[string]string{
+		"api-audiences":            strings.Join(audiences, ","),
+		"service-account-issuer":   issuerURL,
+		"service-account-jwks-uri": fmt.Sprintf("%s/%s", issuerURL, oidc.KeysJSON),
+	}
+}
@@ -0,0 +1,42 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// GetOIDCConfig reads the discovery and JWKS documents served by the
+// apiserver of the given kubeconfig context, an empty context uses the
+// kubeconfig's current context
+func GetOIDCConfig(kubeconfig, kubeContext string) (*oidc.Documents, error) {
+	clientConfig, err := clientcmd.NewClientConfigFromBytes([]byte(kubeconfig))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse kubeconfig")
+	}
+	rawConfig, err := clientConfig.RawConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse kubeconfig")
+	}
+	restConfig, err := clientcmd.NewNonInteractiveClientConfig(rawConfig, kubeContext, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load kubeconfig context: %s", kubeContext)
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := map[string][]byte{}
+	for _, path := range []string{oidc.OpenIDDiscoveryPath, oidc.JWKSDiscoveryPath} {
+		data, err := clientset.Discovery().RESTClient().Get().AbsPath(path).DoRaw(context.Background())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s from %s", path, restConfig.Host)
+		}
+		documents[path] = data
+	}
+	return oidc.ParseDocuments(documents[oidc.OpenIDDiscoveryPath], documents[oidc.JWKSDiscoveryPath])
+}
@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc/oidctest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	testDiscoveryJSON = `{"issuer":"https://somedomain","jwks_uri":"https://somedomain/keys.json","response_types_supported":["id_token"],"subject_types_supported":["public"],"id_token_signing_alg_values_supported":["RS256"]}`
+	testJWKSJSON      = `{"keys":[{"use":"sig","kty":"RSA","kid":"abc","alg":"RS256","n":"xyz","e":"AQAB"}]}`
+)
+
+func TestGetOIDCConfig(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidc.OpenIDDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testDiscoveryJSON))
+	})
+	mux.HandleFunc(oidc.JWKSDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testJWKSJSON))
+	})
+	apiServer := httptest.NewTLSServer(mux)
+	defer apiServer.Close()
+
+	kubeconfig, err := testKubeconfig(apiServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oidcConfig, err := GetOIDCConfig(kubeconfig, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if discoveryJSON, err := oidcConfig.Discovery.Marshal(); err != nil {
+		t.Error(err)
+	} else if discoveryJSON != testDiscoveryJSON {
+		t.Errorf("expected: %s\n, got: %s\n", testDiscoveryJSON, discoveryJSON)
+	}
+	if jwksJSON, err := oidcConfig.JWKS.Marshal(); err != nil {
+		t.Error(err)
+	} else if jwksJSON != testJWKSJSON {
+		t.Errorf("expected: %s\n, got: %s\n", testJWKSJSON, jwksJSON)
+	}
+
+	if _, err := GetOIDCConfig(kubeconfig, "missing"); err == nil {
+		t.Error("expected an error for a missing context")
+	}
+}
+
+func TestGetOIDCConfigNotServed(t *testing.T) {
+	apiServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer apiServer.Close()
+
+	kubeconfig, err := testKubeconfig(apiServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetOIDCConfig(kubeconfig, ""); err == nil {
+		t.Error("expected an error when the apiserver does not serve the oidc documents")
+	}
+}
+
+func TestGetOIDCConfigIssuer(t *testing.T) {
+	issuer, err := oidctest.NewIssuer(oidctest.AlgorithmES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+
+	kubeconfig, err := testKubeconfig(issuer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oidcConfig, err := GetOIDCConfig(kubeconfig, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := issuer.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !oidcConfig.Equal(*expected) {
+		t.Errorf("expected: %+v\n, got: %+v\n", *expected, *oidcConfig)
+	}
+	if err := oidcConfig.Validate(issuer.URL); err != nil {
+		t.Error(err)
+	}
+}
+
+func testKubeconfig(server string) (string, error) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["test"] = &clientcmdapi.Cluster{
+		Server:                server,
+		InsecureSkipTLSVerify: true,
+	}
+	config.AuthInfos["test"] = &clientcmdapi.AuthInfo{
+		Token: "test",
+	}
+	config.Contexts["test"] = &clientcmdapi.Context{
+		Cluster:  "test",
+		AuthInfo: "test",
+	}
+	config.CurrentContext = "test"
+	kubeconfig, err := clientcmd.Write(*config)
+	return string(kubeconfig), err
+}
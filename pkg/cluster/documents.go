@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"reflect"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	// OIDCDocumentsType is the type token of the documents served by a
+	// cluster, managed by the `irsa` provider plugin
+	OIDCDocumentsType = "irsa:index:OIDCDocuments"
+
+	OIDCDocumentsKubeconfig       = "kubeconfig"
+	OIDCDocumentsIssuer           = "issuer"
+	OIDCDocumentsExpectedJWKS     = "expectedJwks"
+	OIDCDocumentsKindCluster      = "kindCluster"
+	OIDCDocumentsKindNodeProvider = "kindNodeProvider"
+	OIDCDocumentsDiscovery        = "discovery"
+	OIDCDocumentsJWKS             = "jwks"
+)
+
+// OIDCDocuments are the discovery document and JWKS served by a cluster,
+// read again from the cluster on every refresh
+type OIDCDocuments struct {
+	pulumi.CustomResourceState
+
+	Kubeconfig       pulumi.StringOutput `pulumi:"kubeconfig"`
+	Issuer           pulumi.StringOutput `pulumi:"issuer"`
+	ExpectedJWKS     pulumi.StringOutput `pulumi:"expectedJwks"`
+	KindCluster      pulumi.StringOutput `pulumi:"kindCluster"`
+	KindNodeProvider pulumi.StringOutput `pulumi:"kindNodeProvider"`
+	Discovery        pulumi.StringOutput `pulumi:"discovery"`
+	JWKS             pulumi.StringOutput `pulumi:"jwks"`
+}
+
+// OIDCDocumentsArgs are the inputs of `OIDCDocuments`
+type OIDCDocumentsArgs struct {
+	Kubeconfig pulumi.StringInput
+	// Issuer is optional, the documents are validated for it when set
+	Issuer pulumi.StringInput
+	// ExpectedJWKS is optional, when set the provider waits for the cluster
+	// to serve it and fails when it does not
+	ExpectedJWKS pulumi.StringInput
+	// KindCluster is optional, when set every control plane node of the kind
+	// cluster is checked to serve the same JWKS through KindNodeProvider
+	KindCluster      pulumi.StringInput
+	KindNodeProvider pulumi.StringInput
+}
+
+type oidcDocumentsArgs struct {
+	Kubeconfig       string  `pulumi:"kubeconfig"`
+	Issuer           *string `pulumi:"issuer"`
+	ExpectedJWKS     *string `pulumi:"expectedJwks"`
+	KindCluster      *string `pulumi:"kindCluster"`
+	KindNodeProvider *string `pulumi:"kindNodeProvider"`
+}
+
+func (OIDCDocumentsArgs) ElementType() reflect.Type {
+	return reflect.TypeOf((*oidcDocumentsArgs)(nil)).Elem()
+}
+
+// NewOIDCDocuments reads the documents served by the cluster of the kubeconfig
+func NewOIDCDocuments(ctx *pulumi.Context, name string, args *OIDCDocumentsArgs, opts ...pulumi.ResourceOption) (*OIDCDocuments, error) {
+	if args == nil || args.Kubeconfig == nil {
+		return nil, errors.New("missing required argument 'Kubeconfig'")
+	}
+	secretArgs := *args
+	secretArgs.Kubeconfig = pulumi.ToSecret(args.Kubeconfig).(pulumi.StringOutput)
+	opts = append(opts, pulumi.AdditionalSecretOutputs([]string{OIDCDocumentsKubeconfig}))
+
+	var resource OIDCDocuments
+	if err := ctx.RegisterResource(OIDCDocumentsType, name, &secretArgs, &resource, opts...); err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// Documents parses the discovery document and JWKS
+func (r *OIDCDocuments) Documents() OIDCDocumentsOutput {
+	return pulumi.All(r.Discovery, r.JWKS).ApplyT(func(args []interface{}) (oidc.Documents, error) {
+		discoveryJSON := args[0].(string)
+		jwksJSON := args[1].(string)
+
+		documents, err := oidc.ParseDocuments([]byte(discoveryJSON), []byte(jwksJSON))
+		if err != nil {
+			return oidc.Documents{}, err
+		}
+		return *documents, nil
+	}).(OIDCDocumentsOutput)
+}
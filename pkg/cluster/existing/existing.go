@@ -0,0 +1,89 @@
+package existing
+
+import (
+	irsacluster "github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// NewExistingConfig uses an already running cluster instead of creating one,
+// the apiserver is expected to already be configured with the issuer flags
+func NewExistingConfig(ctx *pulumi.Context, name string, kubeconfig pulumi.StringInput, kubeContext string) irsacluster.ClusterBackend {
+	return &existingConfig{
+		pulumiContext: ctx,
+		name:          name,
+		kubeconfig:    kubeconfig,
+		kubeContext:   kubeContext,
+	}
+}
+
+func (c *existingConfig) Create(issuer *irsacluster.Issuer, parent pulumi.Resource) (*irsacluster.Cluster, error) {
+	kubeconfig := c.kubeconfig.ToStringOutput().ApplyT(func(kubeconfig string) (string, error) {
+		return withCurrentContext(kubeconfig, c.kubeContext)
+	}).(pulumi.StringOutput)
+
+	// the issuer is verified here rather than by the provider to point at
+	// the apiserver flag to fix
+	oidcDocuments, err := irsacluster.NewOIDCDocuments(c.pulumiContext, c.name, &irsacluster.OIDCDocumentsArgs{
+		Kubeconfig: kubeconfig,
+	}, pulumi.Parent(parent))
+	if err != nil {
+		return nil, err
+	}
+	oidcConfig := pulumi.All(issuer.URL, oidcDocuments.Documents()).ApplyT(func(args []interface{}) (oidc.Documents, error) {
+		issuerURL := args[0].(string)
+		oidcConfig := args[1].(oidc.Documents)
+
+		return oidcConfig, verifyIssuer(oidcConfig.Discovery, issuerURL)
+	}).(irsacluster.OIDCDocumentsOutput)
+
+	return &irsacluster.Cluster{
+		Resource:   parent,
+		Name:       kubeconfig.ApplyT(currentClusterName).(pulumi.StringOutput),
+		Kubeconfig: pulumi.ToSecret(kubeconfig).(pulumi.StringOutput),
+		OIDCConfig: oidcConfig,
+	}, nil
+}
+
+// withCurrentContext switches the kubeconfig to the given context so that
+// the kubernetes providers created from it talk to the same cluster
+func withCurrentContext(kubeconfig, kubeContext string) (string, error) {
+	if kubeContext == "" {
+		return kubeconfig, nil
+	}
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse kubeconfig")
+	}
+	if _, ok := config.Contexts[kubeContext]; !ok {
+		return "", errors.Errorf("context %s not found in kubeconfig", kubeContext)
+	}
+	config.CurrentContext = kubeContext
+	kubeconfigBytes, err := clientcmd.Write(*config)
+	return string(kubeconfigBytes), errors.Wrap(err, "failed to marshal kubeconfig")
+}
+
+// currentClusterName is the kubeconfig name of the cluster the current
+// context points to
+func currentClusterName(kubeconfig string) (string, error) {
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse kubeconfig")
+	}
+	kubeContext, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return "", errors.Errorf("current context %q not found in kubeconfig", config.CurrentContext)
+	}
+	return kubeContext.Cluster, nil
+}
+
+// verifyIssuer makes sure the cluster issues tokens for the hosted issuer,
+// otherwise AWS would reject every token exchange
+func verifyIssuer(discovery oidc.Discovery, issuerURL string) error {
+	if discovery.Issuer != issuerURL {
+		return errors.Errorf("cluster issuer %q does not match the hosting url %q, set the apiserver `--service-account-issuer` flag to %q", discovery.Issuer, issuerURL, issuerURL)
+	}
+	return nil
+}
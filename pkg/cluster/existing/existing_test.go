@@ -0,0 +1,44 @@
+package existing
+
+import (
+	"testing"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestVerifyIssuer(t *testing.T) {
+	if err := verifyIssuer(oidc.Discovery{Issuer: "https://somedomain"}, "https://somedomain"); err != nil {
+		t.Error(err)
+	}
+
+	if err := verifyIssuer(oidc.Discovery{Issuer: "https://kubernetes.default.svc.cluster.local"}, "https://somedomain"); err == nil {
+		t.Error("expected an error for a mismatched issuer")
+	}
+}
+
+func TestCurrentClusterName(t *testing.T) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["kind-aws"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+	config.Contexts["kind-aws"] = &clientcmdapi.Context{Cluster: "kind-aws"}
+	config.CurrentContext = "kind-aws"
+	kubeconfig, err := clientcmd.Write(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name, err := currentClusterName(string(kubeconfig)); err != nil {
+		t.Error(err)
+	} else if name != "kind-aws" {
+		t.Errorf("expected: %s\n, got: %s\n", "kind-aws", name)
+	}
+
+	config.CurrentContext = "missing"
+	if kubeconfig, err = clientcmd.Write(*config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := currentClusterName(string(kubeconfig)); err == nil {
+		t.Error("expected an error for a missing current context")
+	}
+}
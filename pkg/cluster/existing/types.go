@@ -0,0 +1,10 @@
+package existing
+
+import "github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+type existingConfig struct {
+	pulumiContext *pulumi.Context
+	name          string
+	kubeconfig    pulumi.StringInput
+	kubeContext   string
+}
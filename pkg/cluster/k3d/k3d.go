@@ -0,0 +1,81 @@
+package k3d
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	irsacluster "github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/pulumi/pulumi-command/sdk/go/command/local"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func NewK3dConfig(ctx *pulumi.Context, name string) irsacluster.ClusterBackend {
+	return &k3dConfig{
+		pulumiContext: ctx,
+		name:          name,
+	}
+}
+
+func (c *k3dConfig) Create(issuer *irsacluster.Issuer, parent pulumi.Resource) (*irsacluster.Cluster, error) {
+	createCommand := issuer.URL.ApplyT(func(issuerURL string) string {
+		return toK3dCreateCommand(c.name, issuerURL, issuer.Audiences)
+	}).(pulumi.StringOutput)
+
+	// k3d has no notion of updating a cluster in place, so any change
+	// to the issuer flags needs the cluster to be recreated
+	cluster, err := local.NewCommand(c.pulumiContext, c.name, &local.CommandArgs{
+		Create:   createCommand,
+		Delete:   pulumi.Sprintf("k3d cluster delete %s", c.name),
+		Triggers: pulumi.Array{createCommand},
+	}, pulumi.Parent(parent), pulumi.DeleteBeforeReplace(true))
+	if err != nil {
+		return nil, err
+	}
+
+	kubeconfig, err := local.NewCommand(c.pulumiContext, fmt.Sprintf("%s-kubeconfig", c.name), &local.CommandArgs{
+		Create:   pulumi.Sprintf("k3d kubeconfig get %s", c.name),
+		Triggers: pulumi.Array{cluster.ID()},
+	}, pulumi.Parent(cluster), pulumi.AdditionalSecretOutputs([]string{"stdout"}))
+	if err != nil {
+		return nil, err
+	}
+
+	oidcDocuments, err := irsacluster.NewOIDCDocuments(c.pulumiContext, c.name, &irsacluster.OIDCDocumentsArgs{
+		Kubeconfig: kubeconfig.Stdout,
+		Issuer:     issuer.URL,
+	}, pulumi.Parent(cluster))
+	if err != nil {
+		return nil, err
+	}
+
+	return &irsacluster.Cluster{
+		Resource:   cluster,
+		Name:       pulumi.String(c.name).ToStringOutput(),
+		Kubeconfig: kubeconfig.Stdout,
+		OIDCConfig: oidcDocuments.Documents(),
+	}, nil
+}
+
+func toK3dCreateCommand(clusterName, issuerURL string, audiences []string) string {
+	cmd := []string{"k3d", "cluster", "create", clusterName}
+	for _, arg := range toK3sServerArgs(issuerURL, audiences) {
+		cmd = append(cmd, "--k3s-arg", fmt.Sprintf("'%s@server:*'", arg))
+	}
+	return strings.Join(cmd, " ")
+}
+
+func toK3sServerArgs(issuerURL string, audiences []string) []string {
+	apiServerArgs := irsacluster.APIServerIssuerArgs(issuerURL, audiences)
+	keys := make([]string, 0, len(apiServerArgs))
+	for key := range apiServerArgs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	serverArgs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		serverArgs = append(serverArgs, fmt.Sprintf("--kube-apiserver-arg=%s=%s", key, apiServerArgs[key]))
+	}
+	return serverArgs
+}
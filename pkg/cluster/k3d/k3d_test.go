@@ -0,0 +1,30 @@
+package k3d
+
+import (
+	"reflect"
+	"testing"
+
+	irsacluster "github.com/frezbo/irsa-anywhere/pkg/cluster"
+)
+
+var testAudiences = []string{irsacluster.InClusterAudience, irsacluster.STSAudience}
+
+func TestK3sServerArgs(t *testing.T) {
+	expected := []string{
+		"--kube-apiserver-arg=api-audiences=https://kubernetes.default.svc.cluster.local,sts.amazonaws.com",
+		"--kube-apiserver-arg=service-account-issuer=https://somedomain",
+		"--kube-apiserver-arg=service-account-jwks-uri=https://somedomain/keys.json",
+	}
+
+	if actual := toK3sServerArgs("https://somedomain", testAudiences); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %s\n, got: %s\n", expected, actual)
+	}
+}
+
+func TestK3dCreateCommand(t *testing.T) {
+	expected := "k3d cluster create k3d-aws --k3s-arg '--kube-apiserver-arg=api-audiences=https://kubernetes.default.svc.cluster.local,sts.amazonaws.com@server:*' --k3s-arg '--kube-apiserver-arg=service-account-issuer=https://somedomain@server:*' --k3s-arg '--kube-apiserver-arg=service-account-jwks-uri=https://somedomain/keys.json@server:*'"
+
+	if actual := toK3dCreateCommand("k3d-aws", "https://somedomain", testAudiences); actual != expected {
+		t.Errorf("expected: %s\n, got: %s\n", expected, actual)
+	}
+}
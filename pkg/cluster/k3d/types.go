@@ -0,0 +1,8 @@
+package k3d
+
+import "github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+type k3dConfig struct {
+	pulumiContext *pulumi.Context
+	name          string
+}
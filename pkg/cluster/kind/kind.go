@@ -6,89 +6,66 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/frezbo/irsa-anywhere/pkg/apps/irsa"
-	"github.com/frezbo/irsa-anywhere/pkg/apps/sampleapp"
-	awsmeta "github.com/frezbo/irsa-anywhere/pkg/aws/meta"
-	"github.com/frezbo/irsa-anywhere/pkg/component"
+	irsacluster "github.com/frezbo/irsa-anywhere/pkg/cluster"
 	"github.com/frezbo/irsa-anywhere/pkg/oidc"
-	"github.com/frezbo/irsa-anywhere/pkg/resource"
 	"github.com/frezbo/pulumi-provider-kind/sdk/v3/go/kind/cluster"
+	kindconfig "github.com/frezbo/pulumi-provider-kind/sdk/v3/go/kind/config"
+	"github.com/frezbo/pulumi-provider-kind/sdk/v3/go/kind/mount"
 	"github.com/frezbo/pulumi-provider-kind/sdk/v3/go/kind/networking"
-	"github.com/frezbo/pulumi-provider-kind/sdk/v3/go/kind/node"
 	"github.com/pkg/errors"
-	"github.com/pulumi/pulumi-aws/sdk/v4/go/aws/iam"
-	"github.com/pulumi/pulumi-aws/sdk/v4/go/aws/s3"
-	"github.com/pulumi/pulumi-tls/sdk/v4/go/tls"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
-	pulumiconfig "github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeadmv1beta2 "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta2"
+	kubeadmv1beta3 "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta3"
 	kubeadmconstants "k8s.io/kubernetes/cmd/kubeadm/app/constants"
 	kindcluster "sigs.k8s.io/kind/pkg/cluster"
 	"sigs.k8s.io/kind/pkg/cluster/nodes"
 	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
 )
 
-func NewKindConfig(ctx *pulumi.Context, name string) resource.Resource {
+// NewKindConfig creates a kind cluster backend, a nil signingKey keeps the
+// service account signing key generated by kubeadm
+func NewKindConfig(ctx *pulumi.Context, name string, topology *Topology, nodeImage *NodeImage, signingKey *SigningKey) irsacluster.ClusterBackend {
 	return &kindConfig{
 		pulumiContext: ctx,
 		name:          name,
+		topology:      topology,
+		nodeImage:     nodeImage,
+		signingKey:    signingKey,
+		// the pulumi kind provider reads the same config, so both
+		// always agree on the runtime hosting the nodes
+		nodeProvider: kindconfig.GetProvider(ctx),
 	}
 }
 
-func (c *kindConfig) Create() (pulumi.Resource, error) {
-	kindResource, err := component.NewDynamicComponent(c.pulumiContext, c.name)
-	if err != nil {
-		return nil, err
-	}
-
-	commonAwsResourceTags, err := awsmeta.ResourceTags(c.pulumiContext, c.name)
-	if err != nil {
+func (c *kindConfig) Create(issuer *irsacluster.Issuer, parent pulumi.Resource) (*irsacluster.Cluster, error) {
+	if _, err := nodeProviderOption(c.nodeProvider); err != nil {
 		return nil, err
 	}
 
-	bucket, err := s3.NewBucket(c.pulumiContext, c.name, &s3.BucketArgs{
-		Tags: commonAwsResourceTags,
-	}, pulumi.Parent(kindResource))
+	image, kubeadmAPIVersion, err := c.nodeImage.resolve()
 	if err != nil {
 		return nil, err
 	}
 
-	oidcData := bucket.BucketRegionalDomainName.ApplyT(func(domain string) (map[string]string, error) {
-		oidcData := map[string]string{
-			"domain": domain,
-		}
-
-		kubeadmConfigPatch, err := toKubeadmConfigPatchYAML(domain)
-		if err != nil {
-			return oidcData, err
-		}
-		oidcData["kubeadmConfigPatch"] = kubeadmConfigPatch
-
-		certs, err := tls.GetCertificate(c.pulumiContext, &tls.GetCertificateArgs{
-			Url:         fmt.Sprintf("https://%s", domain),
-			VerifyChain: &[]bool{true}[0],
-		})
+	var controlPlaneMounts mount.MountArray
+	var keys *signingKeys
+	clusterOpts := []pulumi.ResourceOption{pulumi.Parent(parent)}
+	if c.signingKey != nil {
+		keys, err = c.newSigningKeys(parent)
 		if err != nil {
-			return oidcData, err
-		}
-
-		for _, cert := range certs.Certificates {
-			if cert.IsCa {
-				oidcData["caFingerprint"] = cert.Sha1Fingerprint
-				break
-			}
+			return nil, err
 		}
+		controlPlaneMounts = keys.mounts()
+		// the keys have to be on the host before the nodes mount them
+		clusterOpts = append(clusterOpts, pulumi.DependsOn([]pulumi.Resource{keys.files}))
+	}
 
-		return oidcData, nil
-	}).(pulumi.StringMapOutput)
+	kubeadmConfigPatch := issuer.URL.ApplyT(func(issuerURL string) (string, error) {
+		return toKubeadmConfigPatchYAML(issuerURL, issuer.Audiences, kubeadmAPIVersion, c.signingKey != nil)
+	}).(pulumi.StringOutput)
 
-	openIDProvider, err := iam.NewOpenIdConnectProvider(c.pulumiContext, c.name, &iam.OpenIdConnectProviderArgs{
-		Url:             pulumi.Sprintf("https://%s", oidcData.MapIndex(pulumi.String("domain"))),
-		ClientIdLists:   pulumi.StringArray{pulumi.String("sts.amazonaws.com")},
-		ThumbprintLists: pulumi.StringArray{oidcData.MapIndex(pulumi.String("caFingerprint"))},
-		Tags:            commonAwsResourceTags,
-	}, pulumi.Parent(kindResource))
+	nodes, err := c.topology.toNodes(kubeadmConfigPatch, controlPlaneMounts, image, kubeadmAPIVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -98,133 +75,189 @@ func (c *kindConfig) Create() (pulumi.Resource, error) {
 		Networking: networking.NetworkingArgs{
 			ApiServerAddress: pulumi.String("0.0.0.0"),
 		},
-		Nodes: node.NodeArray{
-			node.NodeArgs{
-				Role: node.RoleTypeControlPlane,
-				KubeadmConfigPatches: pulumi.StringArray{
-					oidcData.MapIndex(pulumi.String("kubeadmConfigPatch")),
-				},
-			},
-		},
-	}, pulumi.Parent(kindResource))
+		Nodes: nodes,
+	}, clusterOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	oidcConfig := cluster.Name.ApplyT(func(name string) (map[string]string, error) {
-		c.pulumiContext.Log.Info("getting oidc config from cluster...", &pulumi.LogArgs{
-			Resource: cluster,
-		})
-		return getOIDCConfig(name)
-	}).(pulumi.StringMapOutput)
-
-	if _, err := s3.NewBucketObject(c.pulumiContext, fmt.Sprintf("%s-discovery", c.name), &s3.BucketObjectArgs{
-		Acl:     s3.CannedAclPublicRead,
-		Bucket:  bucket.ID(),
-		Content: oidcConfig.MapIndex(pulumi.String(oidc.DiscoveryJSON)),
-		Key:     pulumi.String(oidc.OpenIDDiscoveryPath),
-		Tags:    commonAwsResourceTags,
-	}, pulumi.Parent(bucket)); err != nil {
-		return nil, err
+	oidcDocumentsArgs := &irsacluster.OIDCDocumentsArgs{
+		Kubeconfig: cluster.Kubeconfig,
+		Issuer:     issuer.URL,
 	}
-
-	if _, err := s3.NewBucketObject(c.pulumiContext, fmt.Sprintf("%s-jwks", c.name), &s3.BucketObjectArgs{
-		Acl:     s3.CannedAclPublicRead,
-		Bucket:  bucket.ID(),
-		Content: oidcConfig.MapIndex(pulumi.String(oidc.KeysJSON)),
-		Key:     pulumi.String(oidc.KeysJSON),
-		Tags:    commonAwsResourceTags,
-	}, pulumi.Parent(bucket)); err != nil {
-		return nil, err
+	// the kubeconfig only reaches the control plane nodes through the
+	// kind load balancer, so each node is checked through the node provider
+	if c.topology.ControlPlane.Count > 1 {
+		oidcDocumentsArgs.KindCluster = cluster.Name
+		oidcDocumentsArgs.KindNodeProvider = pulumi.String(c.nodeProvider)
 	}
 
-	irsaApp := irsa.NewIRSAConfig(c.pulumiContext, c.name, cluster.Kubeconfig, kindResource)
-	irsaResource, err := irsaApp.Create()
-	if err != nil {
-		return nil, err
-	}
+	oidcDocumentsOpts := []pulumi.ResourceOption{pulumi.Parent(cluster)}
+	if keys != nil {
+		// the cluster is only handed out once it serves the JWKS built from
+		// the keys tracked in the stack
+		oidcDocumentsArgs.ExpectedJWKS = keys.jwks
 
-	cfg := pulumiconfig.New(c.pulumiContext, "")
-	if cfg.Get("createSampleApp") == "true" {
-		sampleAppConfig := sampleapp.NewSampleAppConfig(c.pulumiContext, bucket.BucketRegionalDomainName, openIDProvider.Arn, cluster.Kubeconfig, kindResource, []pulumi.Resource{irsaResource})
-		if _, err := sampleAppConfig.Create(); err != nil {
+		restart, err := keys.restartControlPlane(c.pulumiContext, c.name, c.nodeProvider, cluster.Name, cluster)
+		if err != nil {
 			return nil, err
 		}
+		oidcDocumentsOpts = append(oidcDocumentsOpts, pulumi.DependsOn([]pulumi.Resource{restart}))
+	}
 
+	oidcDocuments, err := irsacluster.NewOIDCDocuments(c.pulumiContext, c.name, oidcDocumentsArgs, oidcDocumentsOpts...)
+	if err != nil {
+		return nil, err
 	}
 
-	return cluster, nil
+	return &irsacluster.Cluster{
+		Resource:   cluster,
+		Name:       cluster.Name,
+		Kubeconfig: oidcDocuments.Kubeconfig,
+		OIDCConfig: oidcDocuments.Documents(),
+	}, nil
 }
 
-func toKubeadmConfigPatchYAML(issuerURL string) (string, error) {
-	clusterConfig := &kubeadmv1beta2.ClusterConfiguration{
-		TypeMeta: metav1.TypeMeta{
-			Kind: kubeadmconstants.ClusterConfigurationKind,
-		},
-		APIServer: kubeadmv1beta2.APIServer{
-			ControlPlaneComponent: kubeadmv1beta2.ControlPlaneComponent{
-				ExtraArgs: map[string]string{
-					"api-audiences":            "https://kubernetes.default.svc.cluster.local,sts.amazonaws.com",
-					"service-account-issuer":   fmt.Sprintf("https://%s", issuerURL),
-					"service-account-jwks-uri": fmt.Sprintf("https://%s/%s", issuerURL, oidc.KeysJSON),
+func toKubeadmConfigPatchYAML(issuerURL string, audiences []string, kubeadmAPIVersion string, dedicatedSigningKey bool) (string, error) {
+	apiServerArgs := irsacluster.APIServerIssuerArgs(issuerURL, audiences)
+	var controllerManagerArgs map[string]string
+	if dedicatedSigningKey {
+		signingKeyAPIServerArgs, signingKeyControllerManagerArgs := signingKeyArgs()
+		for key, value := range signingKeyAPIServerArgs {
+			apiServerArgs[key] = value
+		}
+		controllerManagerArgs = signingKeyControllerManagerArgs
+	}
+
+	var clusterConfig interface{}
+	switch kubeadmAPIVersion {
+	case kubeadmV1beta2:
+		clusterConfig = &kubeadmv1beta2.ClusterConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				Kind: kubeadmconstants.ClusterConfigurationKind,
+			},
+			APIServer: kubeadmv1beta2.APIServer{
+				ControlPlaneComponent: kubeadmv1beta2.ControlPlaneComponent{
+					ExtraArgs: apiServerArgs,
 				},
 			},
-		},
+			ControllerManager: kubeadmv1beta2.ControlPlaneComponent{
+				ExtraArgs: controllerManagerArgs,
+			},
+		}
+	case kubeadmV1beta3:
+		clusterConfig = &kubeadmv1beta3.ClusterConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				Kind: kubeadmconstants.ClusterConfigurationKind,
+			},
+			APIServer: kubeadmv1beta3.APIServer{
+				ControlPlaneComponent: kubeadmv1beta3.ControlPlaneComponent{
+					ExtraArgs: apiServerArgs,
+				},
+			},
+			ControllerManager: kubeadmv1beta3.ControlPlaneComponent{
+				ExtraArgs: controllerManagerArgs,
+			},
+		}
+	case kubeadmV1beta4:
+		clusterConfig = newClusterConfigurationV1beta4(apiServerArgs, controllerManagerArgs)
+	default:
+		return "", errors.Errorf("unsupported kubeadm api version: %s", kubeadmAPIVersion)
 	}
 	clusterConfigBytes, err := json.Marshal(clusterConfig)
 	return string(clusterConfigBytes), errors.Wrapf(err, "failed to marshal kubeadm cluster config yaml")
 }
 
-func getOIDCConfig(clusterName string) (map[string]string, error) {
-	oidcConfig := map[string]string{}
-	nodes, err := getNodes(clusterName)
-	if err != nil {
-		return oidcConfig, err
+func getNodes(clusterName string, nodeProvider kindcluster.ProviderOption) ([]nodes.Node, error) {
+	prov := kindcluster.NewProvider(nodeProvider)
+	nodes, err := prov.ListNodes(clusterName)
+	return nodes, errors.Wrapf(err, fmt.Sprintf("unable to find a cluster: %s", clusterName))
+}
+
+// nodeProviderOption maps the `kind:provider` config to the kind node provider,
+// defaulting to docker same as the pulumi kind provider. Only the runtimes the
+// pulumi kind provider creates clusters with are accepted, so that both always
+// look at the same nodes
+func nodeProviderOption(nodeProvider string) (kindcluster.ProviderOption, error) {
+	if err := ValidateNodeProvider(nodeProvider); err != nil {
+		return nil, err
+	}
+	if nodeProvider == NodeProviderPodman {
+		return kindcluster.ProviderWithPodman(), nil
 	}
+	return kindcluster.ProviderWithDocker(), nil
+}
 
-	cpNode, err := getControlPlaneNode(nodes)
-	if err != nil {
-		return oidcConfig, err
+// ValidateNodeProvider checks the `kind:provider` config is one of the
+// supported node providers, empty meaning docker
+func ValidateNodeProvider(nodeProvider string) error {
+	if nodeProvider == "" {
+		return nil
 	}
-	cmdArgs := []string{
-		"--kubeconfig",
-		"/etc/kubernetes/admin.conf",
-		"get",
-		"--raw",
+	for _, supported := range supportedNodeProviders {
+		if nodeProvider == supported {
+			return nil
+		}
+	}
+	if nodeProvider == NodeProviderNerdctl {
+		return errors.Errorf("unsupported kind node provider: %s, the pulumi kind provider only creates clusters with %s", nodeProvider, strings.Join(supportedNodeProviders, " or "))
+	}
+	return errors.Errorf("unsupported kind node provider: %s, must be one of %s", nodeProvider, strings.Join(supportedNodeProviders, " or "))
+}
+
+// VerifyControlPlaneJWKS makes sure every control plane node of the cluster
+// serves the expected JWKS since tokens can be signed by any of them, the
+// nodes are looked up through the `kind:provider` runtime
+func VerifyControlPlaneJWKS(clusterName, nodeProvider string, jwks oidc.JSONWebKeySet) error {
+	providerOption, err := nodeProviderOption(nodeProvider)
+	if err != nil {
+		return err
 	}
-	jwksJSON, err := runCommand(cpNode, "kubectl", append(cmdArgs, oidc.JWKSDiscoveryPath))
+	nodes, err := getNodes(clusterName, providerOption)
 	if err != nil {
-		return oidcConfig, err
+		return err
 	}
-	discoveryJSON, err := runCommand(cpNode, "kubectl", append(cmdArgs, oidc.OpenIDDiscoveryPath))
+
+	cpNodes, err := nodeutils.ControlPlaneNodes(nodes)
 	if err != nil {
-		return oidcConfig, err
+		return errors.Wrapf(err, "unable to find a control plane node for cluster")
+	}
+	if len(cpNodes) < 1 {
+		return errors.New("cannot find any control plane nodes, is the kind cluster running...?")
 	}
 
-	oidcConfig[oidc.DiscoveryJSON] = discoveryJSON
-	oidcConfig[oidc.KeysJSON] = jwksJSON
-	return oidcConfig, nil
+	for _, cpNode := range cpNodes {
+		nodeJWKSJSON, err := runCommand(cpNode, "kubectl", append(kubectlGetRawArgs(), oidc.JWKSDiscoveryPath))
+		if err != nil {
+			return err
+		}
+		same, err := sameJWKS(jwks, nodeJWKSJSON)
+		if err != nil {
+			return err
+		}
+		if !same {
+			return errors.Errorf("control plane node %s serves a different JWKS than the apiserver", cpNode.String())
+		}
+	}
+	return nil
 }
 
-func getNodes(clusterName string) ([]nodes.Node, error) {
-	// just trying to auto-detect the provider
-	// this might fail when the pulumi kind
-	// `provider` is set to a provider that is not auto-detected
-	kindclusterProvider, err := kindcluster.DetectNodeProvider()
-	if err != nil {
-		return []nodes.Node{}, err
+func kubectlGetRawArgs() []string {
+	return []string{
+		"--kubeconfig",
+		"/etc/kubernetes/admin.conf",
+		"get",
+		"--raw",
 	}
-	prov := kindcluster.NewProvider(kindclusterProvider)
-	nodes, err := prov.ListNodes(clusterName)
-	return nodes, errors.Wrapf(err, fmt.Sprintf("unable to find a cluster: %s", clusterName))
 }
 
-func getControlPlaneNode(nodes []nodes.Node) (nodes.Node, error) {
-	if len(nodes) < 1 {
-		return nil, errors.New("cannot find any control plane nodes, is the kind cluster running...?")
+// sameJWKS compares the JWKS served by a node with the expected one
+func sameJWKS(expected oidc.JSONWebKeySet, nodeJWKSJSON string) (bool, error) {
+	nodeJWKS, err := oidc.ParseJWKS([]byte(nodeJWKSJSON))
+	if err != nil {
+		return false, err
 	}
-	cpNodes, err := nodeutils.ControlPlaneNodes(nodes)
-	return cpNodes[0], errors.Wrapf(err, "unable to find a control plane node for cluster")
+	return expected.Equal(*nodeJWKS), nil
 }
 
 func runCommand(node nodes.Node, command string, args []string) (string, error) {
@@ -1,15 +1,92 @@
 package kind
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	irsacluster "github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
 )
 
+var testAudiences = []string{irsacluster.InClusterAudience, irsacluster.STSAudience}
+
 func TestKubeadmconfigPatch(t *testing.T) {
 	expected := `{"kind":"ClusterConfiguration","etcd":{},"networking":{},"apiServer":{"extraArgs":{"api-audiences":"https://kubernetes.default.svc.cluster.local,sts.amazonaws.com","service-account-issuer":"https://somedomain","service-account-jwks-uri":"https://somedomain/keys.json"}},"controllerManager":{},"scheduler":{},"dns":{"type":""}}`
 
-	if actual, err := toKubeadmConfigPatchYAML("somedomain"); err != nil {
+	if actual, err := toKubeadmConfigPatchYAML("https://somedomain", testAudiences, kubeadmV1beta2, false); err != nil {
+		t.Error(err)
+	} else if actual != expected {
+		t.Errorf("expected: %s\n, got: %s\n", expected, actual)
+	}
+}
+
+func TestKubeadmConfigPatchGolden(t *testing.T) {
+	for _, kubeadmAPIVersion := range []string{kubeadmV1beta2, kubeadmV1beta3, kubeadmV1beta4} {
+		for _, dedicatedSigningKey := range []bool{false, true} {
+			goldenName := fmt.Sprintf("cluster-configuration-%s", filepath.Base(kubeadmAPIVersion))
+			if dedicatedSigningKey {
+				goldenName += "-signing-key"
+			}
+			expected, err := os.ReadFile(filepath.Join("testdata", goldenName+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual, err := toKubeadmConfigPatchYAML("https://somedomain", testAudiences, kubeadmAPIVersion, dedicatedSigningKey); err != nil {
+				t.Error(err)
+			} else if actual != strings.TrimSpace(string(expected)) {
+				t.Errorf("%s: expected: %s\n, got: %s\n", goldenName, expected, actual)
+			}
+		}
+	}
+
+	if _, err := toKubeadmConfigPatchYAML("https://somedomain", testAudiences, "kubeadm.k8s.io/v1beta1", false); err == nil {
+		t.Error("expected an error for an unsupported kubeadm api version")
+	}
+}
+
+func TestKubeadmConfigPatchSigningKey(t *testing.T) {
+	expected := `{"kind":"ClusterConfiguration","etcd":{},"networking":{},"apiServer":{"extraArgs":{"api-audiences":"https://kubernetes.default.svc.cluster.local,sts.amazonaws.com","service-account-issuer":"https://somedomain","service-account-jwks-uri":"https://somedomain/keys.json","service-account-key-file":"/etc/kubernetes/pki/irsa/sa.pub","service-account-signing-key-file":"/etc/kubernetes/pki/irsa/sa.key"}},"controllerManager":{"extraArgs":{"service-account-private-key-file":"/etc/kubernetes/pki/irsa/sa.key"}},"scheduler":{},"dns":{}}`
+
+	if actual, err := toKubeadmConfigPatchYAML("https://somedomain", testAudiences, kubeadmV1beta3, true); err != nil {
 		t.Error(err)
 	} else if actual != expected {
 		t.Errorf("expected: %s\n, got: %s\n", expected, actual)
 	}
 }
+
+func TestSameJWKS(t *testing.T) {
+	jwks := oidc.JSONWebKeySet{Keys: []oidc.JSONWebKey{{Use: "sig", Kty: "RSA", Kid: "abc", Alg: "RS256", N: "xyz", E: "AQAB"}}}
+	reformatted := `{"keys": [{"kid": "abc", "kty": "RSA", "use": "sig", "alg": "RS256", "e": "AQAB", "n": "xyz"}]}`
+	other := `{"keys":[{"use":"sig","kty":"RSA","kid":"def","alg":"RS256","n":"xyz","e":"AQAB"}]}`
+
+	if same, err := sameJWKS(jwks, reformatted); err != nil {
+		t.Error(err)
+	} else if !same {
+		t.Errorf("expected %+v and %s to be the same", jwks, reformatted)
+	}
+
+	if same, err := sameJWKS(jwks, other); err != nil {
+		t.Error(err)
+	} else if same {
+		t.Errorf("expected %+v and %s to differ", jwks, other)
+	}
+}
+
+func TestNodeProviderOption(t *testing.T) {
+	for _, nodeProvider := range []string{"", NodeProviderDocker, NodeProviderPodman} {
+		if _, err := nodeProviderOption(nodeProvider); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// the pulumi kind provider cannot create nerdctl clusters
+	for _, nodeProvider := range []string{NodeProviderNerdctl, "containerd", "Docker"} {
+		if _, err := nodeProviderOption(nodeProvider); err == nil {
+			t.Errorf("expected an error for the unsupported node provider %s", nodeProvider)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package kind
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	kubeadmconstants "k8s.io/kubernetes/cmd/kubeadm/app/constants"
+)
+
+// clusterConfigurationV1beta4 declares the fields of the kubeadm v1beta4
+// ClusterConfiguration set by the patch, the vendored kubeadm API predates
+// v1beta4 which turned `extraArgs` into a list of name and value pairs
+type clusterConfigurationV1beta4 struct {
+	Kind              string                       `json:"kind"`
+	APIServer         controlPlaneComponentV1beta4 `json:"apiServer"`
+	ControllerManager controlPlaneComponentV1beta4 `json:"controllerManager"`
+}
+
+type controlPlaneComponentV1beta4 struct {
+	ExtraArgs []argV1beta4 `json:"extraArgs,omitempty"`
+}
+
+type argV1beta4 struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// initConfigurationV1beta4 and joinConfigurationV1beta4 declare the node
+// registration set by the taint patches, which v1beta4 kept as in v1beta3
+type initConfigurationV1beta4 struct {
+	Kind             string                         `json:"kind"`
+	NodeRegistration nodeRegistrationOptionsV1beta4 `json:"nodeRegistration"`
+}
+
+type joinConfigurationV1beta4 struct {
+	Kind             string                         `json:"kind"`
+	NodeRegistration nodeRegistrationOptionsV1beta4 `json:"nodeRegistration"`
+}
+
+type nodeRegistrationOptionsV1beta4 struct {
+	Taints []corev1.Taint `json:"taints"`
+}
+
+func newClusterConfigurationV1beta4(apiServerArgs, controllerManagerArgs map[string]string) *clusterConfigurationV1beta4 {
+	return &clusterConfigurationV1beta4{
+		Kind: kubeadmconstants.ClusterConfigurationKind,
+		APIServer: controlPlaneComponentV1beta4{
+			ExtraArgs: toArgsV1beta4(apiServerArgs),
+		},
+		ControllerManager: controlPlaneComponentV1beta4{
+			ExtraArgs: toArgsV1beta4(controllerManagerArgs),
+		},
+	}
+}
+
+// toArgsV1beta4 sorts the args by name, same as maps are marshalled
+func toArgsV1beta4(args map[string]string) []argV1beta4 {
+	var list []argV1beta4
+	for name, value := range args {
+		list = append(list, argV1beta4{Name: name, Value: value})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
@@ -0,0 +1,170 @@
+package kind
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	// RotationPhaseStable signs and verifies tokens with a single key
+	RotationPhaseStable = "stable"
+	// RotationPhaseOverlap publishes the next key while still signing with the current one
+	RotationPhaseOverlap = "overlap"
+	// RotationPhaseSwitched signs with the next key while the previous key is
+	// still published until every token it signed has expired
+	RotationPhaseSwitched = "switched"
+
+	// SigningKeyRotationType is the type token of the rotation state, managed
+	// by the `irsa` provider plugin
+	SigningKeyRotationType = "irsa:index:SigningKeyRotation"
+
+	SigningKeyRotationGeneration        = "generation"
+	SigningKeyRotationMaxTokenLifetime  = "maxTokenLifetime"
+	SigningKeyRotationSigningGeneration = "signingGeneration"
+	SigningKeyRotationPhase             = "phase"
+	SigningKeyRotationSwitchedAt        = "switchedAt"
+)
+
+// RotationState is kept by the `SigningKeyRotation` resource so that the
+// next `pulumi up` continues the rotation from where the previous one stopped
+type RotationState struct {
+	// Generation is the generation of the key signing tokens
+	Generation int
+	Phase      string
+	// SwitchedAt is when the apiserver started signing with Generation
+	SwitchedAt string
+}
+
+// InitialRotationState is the state of a new rotation resource, no token was
+// signed before so the desired key is used right away
+func InitialRotationState(signingKey *SigningKey) RotationState {
+	return RotationState{
+		Generation: signingKey.Generation,
+		Phase:      RotationPhaseStable,
+	}
+}
+
+// NextRotationState moves the rotation at most one phase forward towards the
+// desired key generation, so every phase is applied by its own `pulumi up`.
+// Only the keys of the desired generation and the one before it are declared,
+// a rotation needing any other key has to wait for the current one to complete
+func NextRotationState(previous RotationState, signingKey *SigningKey, now time.Time) (RotationState, error) {
+	state, err := nextRotationState(previous, signingKey, now)
+	if err != nil {
+		return state, err
+	}
+	for _, generation := range state.verificationGenerations() {
+		if generation != signingKey.Generation && generation != signingKey.Generation-1 {
+			return previous, errors.Errorf("service account key generation %d is still used in the %s phase of generation %d, rotate one generation at a time", generation, state.Phase, state.Generation)
+		}
+	}
+	return state, nil
+}
+
+func nextRotationState(previous RotationState, signingKey *SigningKey, now time.Time) (RotationState, error) {
+	switch previous.Phase {
+	case RotationPhaseStable:
+		if signingKey.Generation < previous.Generation {
+			return previous, errors.Errorf("service account key generation cannot go back from %d to %d", previous.Generation, signingKey.Generation)
+		}
+		if signingKey.Generation > previous.Generation {
+			return RotationState{
+				Generation: previous.Generation,
+				Phase:      RotationPhaseOverlap,
+			}, nil
+		}
+		return previous, nil
+	case RotationPhaseOverlap:
+		// nothing was signed by the next key yet, so the rotation can still be aborted
+		if signingKey.Generation == previous.Generation {
+			return RotationState{
+				Generation: previous.Generation,
+				Phase:      RotationPhaseStable,
+			}, nil
+		}
+		return RotationState{
+			Generation: previous.Generation + 1,
+			Phase:      RotationPhaseSwitched,
+			SwitchedAt: now.UTC().Format(time.RFC3339),
+		}, nil
+	case RotationPhaseSwitched:
+		switchedAt, err := time.Parse(time.RFC3339, previous.SwitchedAt)
+		if err != nil {
+			return previous, errors.Wrapf(err, "failed to parse the key switch time: %s", previous.SwitchedAt)
+		}
+		if now.Sub(switchedAt) < signingKey.MaxTokenLifetime {
+			return previous, nil
+		}
+		return RotationState{
+			Generation: previous.Generation,
+			Phase:      RotationPhaseStable,
+		}, nil
+	default:
+		return previous, errors.Errorf("unknown service account key rotation phase: %s", previous.Phase)
+	}
+}
+
+// verificationGenerations returns the generations of every key tokens are
+// verified with in the state's phase, the signing key first
+func (s RotationState) verificationGenerations() []int {
+	switch s.Phase {
+	case RotationPhaseOverlap:
+		return []int{s.Generation, s.Generation + 1}
+	case RotationPhaseSwitched:
+		return []int{s.Generation, s.Generation - 1}
+	default:
+		return []int{s.Generation}
+	}
+}
+
+// declaredKeyGenerations are the generations of the keys declared for the
+// desired generation, the previous one is kept for the phases of a rotation
+func declaredKeyGenerations(generation int) []int {
+	if generation == 0 {
+		return []int{generation}
+	}
+	return []int{generation - 1, generation}
+}
+
+// SigningKeyRotation tracks the rotation phase of the service account keys
+type SigningKeyRotation struct {
+	pulumi.CustomResourceState
+
+	Generation        pulumi.IntOutput    `pulumi:"generation"`
+	MaxTokenLifetime  pulumi.StringOutput `pulumi:"maxTokenLifetime"`
+	SigningGeneration pulumi.IntOutput    `pulumi:"signingGeneration"`
+	Phase             pulumi.StringOutput `pulumi:"phase"`
+	SwitchedAt        pulumi.StringOutput `pulumi:"switchedAt"`
+}
+
+// SigningKeyRotationArgs are the inputs of `SigningKeyRotation`
+type SigningKeyRotationArgs struct {
+	// Generation is the desired key generation
+	Generation pulumi.IntInput
+	// MaxTokenLifetime is a duration, e.g. `24h`
+	MaxTokenLifetime pulumi.StringInput
+}
+
+type signingKeyRotationArgs struct {
+	Generation       int    `pulumi:"generation"`
+	MaxTokenLifetime string `pulumi:"maxTokenLifetime"`
+}
+
+func (SigningKeyRotationArgs) ElementType() reflect.Type {
+	return reflect.TypeOf((*signingKeyRotationArgs)(nil)).Elem()
+}
+
+// NewSigningKeyRotation registers the rotation state of the service account keys
+func NewSigningKeyRotation(ctx *pulumi.Context, name string, args *SigningKeyRotationArgs, opts ...pulumi.ResourceOption) (*SigningKeyRotation, error) {
+	if args == nil || args.Generation == nil || args.MaxTokenLifetime == nil {
+		return nil, errors.New("missing required argument 'Generation' or 'MaxTokenLifetime'")
+	}
+	var resource SigningKeyRotation
+	if err := ctx.RegisterResource(SigningKeyRotationType, name, args, &resource, opts...); err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
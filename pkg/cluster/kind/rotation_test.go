@@ -0,0 +1,110 @@
+package kind
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNextRotationState(t *testing.T) {
+	now := time.Date(2021, 11, 5, 9, 0, 0, 0, time.UTC)
+	signingKey := &SigningKey{
+		Generation:       1,
+		MaxTokenLifetime: 24 * time.Hour,
+	}
+
+	tests := []struct {
+		previous RotationState
+		expected RotationState
+	}{
+		{
+			previous: RotationState{Generation: 0, Phase: RotationPhaseStable},
+			expected: RotationState{Generation: 0, Phase: RotationPhaseOverlap},
+		},
+		{
+			previous: RotationState{Generation: 0, Phase: RotationPhaseOverlap},
+			expected: RotationState{Generation: 1, Phase: RotationPhaseSwitched, SwitchedAt: "2021-11-05T09:00:00Z"},
+		},
+		{
+			previous: RotationState{Generation: 1, Phase: RotationPhaseSwitched, SwitchedAt: "2021-11-05T08:00:00Z"},
+			expected: RotationState{Generation: 1, Phase: RotationPhaseSwitched, SwitchedAt: "2021-11-05T08:00:00Z"},
+		},
+		{
+			previous: RotationState{Generation: 1, Phase: RotationPhaseSwitched, SwitchedAt: "2021-11-04T09:00:00Z"},
+			expected: RotationState{Generation: 1, Phase: RotationPhaseStable},
+		},
+		{
+			previous: RotationState{Generation: 1, Phase: RotationPhaseStable},
+			expected: RotationState{Generation: 1, Phase: RotationPhaseStable},
+		},
+		{
+			previous: RotationState{Generation: 1, Phase: RotationPhaseOverlap},
+			expected: RotationState{Generation: 1, Phase: RotationPhaseStable},
+		},
+	}
+
+	for _, test := range tests {
+		if actual, err := nextRotationState(test.previous, signingKey, now); err != nil {
+			t.Error(err)
+		} else if actual != test.expected {
+			t.Errorf("from %+v expected: %+v\n, got: %+v\n", test.previous, test.expected, actual)
+		}
+	}
+
+	if _, err := nextRotationState(RotationState{Generation: 2, Phase: RotationPhaseStable}, signingKey, now); err == nil {
+		t.Error("expected an error when the key generation goes back")
+	}
+}
+
+func TestNextRotationStateGenerations(t *testing.T) {
+	now := time.Date(2021, 11, 5, 9, 0, 0, 0, time.UTC)
+	signingKey := &SigningKey{
+		Generation:       2,
+		MaxTokenLifetime: 24 * time.Hour,
+	}
+
+	// the key of generation 0 is no longer declared once 2 is desired
+	previous := RotationState{Generation: 1, Phase: RotationPhaseSwitched, SwitchedAt: "2021-11-05T08:00:00Z"}
+	if _, err := NextRotationState(previous, signingKey, now); err == nil {
+		t.Error("expected an error when skipping a generation mid rotation")
+	}
+
+	previous = RotationState{Generation: 0, Phase: RotationPhaseStable}
+	if _, err := NextRotationState(previous, signingKey, now); err == nil {
+		t.Error("expected an error when rotating more than one generation")
+	}
+
+	previous = RotationState{Generation: 1, Phase: RotationPhaseStable}
+	expected := RotationState{Generation: 1, Phase: RotationPhaseOverlap}
+	if actual, err := NextRotationState(previous, signingKey, now); err != nil {
+		t.Error(err)
+	} else if actual != expected {
+		t.Errorf("expected: %+v\n, got: %+v\n", expected, actual)
+	}
+}
+
+func TestVerificationGenerations(t *testing.T) {
+	tests := []struct {
+		state        RotationState
+		verification []int
+	}{
+		{
+			state:        RotationState{Generation: 0, Phase: RotationPhaseStable},
+			verification: []int{0},
+		},
+		{
+			state:        RotationState{Generation: 0, Phase: RotationPhaseOverlap},
+			verification: []int{0, 1},
+		},
+		{
+			state:        RotationState{Generation: 1, Phase: RotationPhaseSwitched},
+			verification: []int{1, 0},
+		},
+	}
+
+	for _, test := range tests {
+		if verification := test.state.verificationGenerations(); !reflect.DeepEqual(verification, test.verification) {
+			t.Errorf("%+v expected: %v\n, got: %v\n", test.state, test.verification, verification)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package kind
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/frezbo/pulumi-provider-kind/sdk/v3/go/kind/mount"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi-command/sdk/go/command/local"
+	"github.com/pulumi/pulumi-tls/sdk/v4/go/tls"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	SigningKeyAlgorithmRSA   = "RSA"
+	SigningKeyAlgorithmECDSA = "ECDSA"
+
+	// kubeadm mounts `/etc/kubernetes/pki` into both the apiserver and the
+	// controller manager pods, so the keys are visible to them from here
+	signingKeyNodeDir   = "/etc/kubernetes/pki/irsa"
+	signingKeyFile      = "sa.key"
+	verificationKeyFile = "sa.pub"
+
+	signingKeyEnv       = "IRSA_SIGNING_KEY"
+	verificationKeysEnv = "IRSA_VERIFICATION_KEYS"
+	keyIDsEnv           = "IRSA_KEY_IDS"
+
+	// labels kind sets on the node containers
+	kindClusterLabel = "io.x-k8s.kind.cluster"
+	kindRoleLabel    = "io.x-k8s.kind.role"
+)
+
+// defaultMaxTokenLifetime matches the longest token lifetime the webhook
+// projects by default
+const defaultMaxTokenLifetime = 24 * time.Hour
+
+// NewSigningKey returns the stack managed signing key of the algorithm, nil
+// when the algorithm is empty and kubeadm generates the key. An empty
+// maxTokenLifetime defaults to a day
+func NewSigningKey(algorithm string, generation int, maxTokenLifetime string) (*SigningKey, error) {
+	if algorithm == "" {
+		return nil, nil
+	}
+	if algorithm != SigningKeyAlgorithmRSA && algorithm != SigningKeyAlgorithmECDSA {
+		return nil, errors.Errorf("unsupported service account signing key algorithm: %s, must be one of %s or %s", algorithm, SigningKeyAlgorithmRSA, SigningKeyAlgorithmECDSA)
+	}
+	if generation < 0 {
+		return nil, errors.Errorf("service account key generation %d must not be negative", generation)
+	}
+	signingKey := &SigningKey{
+		Algorithm:        algorithm,
+		Generation:       generation,
+		MaxTokenLifetime: defaultMaxTokenLifetime,
+	}
+	if maxTokenLifetime != "" {
+		var err error
+		if signingKey.MaxTokenLifetime, err = time.ParseDuration(maxTokenLifetime); err != nil {
+			return nil, errors.Wrap(err, "failed to parse the max token lifetime")
+		}
+	}
+	return signingKey, nil
+}
+
+// signingKeys are the keys of the current rotation phase written to the host
+type signingKeys struct {
+	hostDir string
+	// files writes the keys to hostDir
+	files pulumi.Resource
+	// publicKeysPem holds every key the apiserver verifies tokens with
+	publicKeysPem pulumi.StringOutput
+	// jwks is built from the same keys, it is what the cluster has to serve
+	jwks pulumi.StringOutput
+	// keyIDs identify the signing key and the verification keys, a change
+	// means the control plane has to load the keys again
+	keyIDs pulumi.StringOutput
+}
+
+// newSigningKeys declares the keys of the desired generation and the one
+// before it, the rotation state picks the keys signing and verifying tokens
+// from them, which are written to the host to be mounted into the control
+// plane nodes
+func (c *kindConfig) newSigningKeys(parent pulumi.Resource) (*signingKeys, error) {
+	keyArgs := &tls.PrivateKeyArgs{
+		Algorithm: pulumi.String(c.signingKey.Algorithm),
+	}
+	switch c.signingKey.Algorithm {
+	case SigningKeyAlgorithmRSA:
+		keyArgs.RsaBits = pulumi.IntPtr(2048)
+	case SigningKeyAlgorithmECDSA:
+		keyArgs.EcdsaCurve = pulumi.StringPtr("P256")
+	default:
+		return nil, errors.Errorf("unsupported service account signing key algorithm: %s, must be one of %s or %s", c.signingKey.Algorithm, SigningKeyAlgorithmRSA, SigningKeyAlgorithmECDSA)
+	}
+
+	hostDir, err := signingKeyHostDir(c.pulumiContext.Project(), c.pulumiContext.Stack(), c.name)
+	if err != nil {
+		return nil, err
+	}
+	// the directory is single quoted in the commands writing the keys
+	if strings.Contains(hostDir, "'") {
+		return nil, errors.Errorf("service account key directory %s must not contain a single quote", hostDir)
+	}
+
+	rotation, err := NewSigningKeyRotation(c.pulumiContext, fmt.Sprintf("%s-service-account-rotation", c.name), &SigningKeyRotationArgs{
+		Generation:       pulumi.Int(c.signingKey.Generation),
+		MaxTokenLifetime: pulumi.String(c.signingKey.MaxTokenLifetime.String()),
+	}, pulumi.Parent(parent))
+	if err != nil {
+		return nil, err
+	}
+
+	// keys of generations no longer declared are deleted from the stack
+	generations := declaredKeyGenerations(c.signingKey.Generation)
+	privateKeyPems := []interface{}{rotation.SigningGeneration}
+	publicKeyPems := []interface{}{rotation.SigningGeneration, rotation.Phase}
+	for _, generation := range generations {
+		key, err := tls.NewPrivateKey(c.pulumiContext, signingKeyName(c.name, generation), keyArgs, pulumi.Parent(parent), pulumi.AdditionalSecretOutputs([]string{"privateKeyPem"}))
+		if err != nil {
+			return nil, err
+		}
+		privateKeyPems = append(privateKeyPems, key.PrivateKeyPem)
+		publicKeyPems = append(publicKeyPems, key.PublicKeyPem)
+	}
+
+	signingKeyPem := pulumi.All(privateKeyPems...).ApplyT(func(args []interface{}) (string, error) {
+		return keyOfGeneration(generations, args[0].(int), toStrings(args[1:]))
+	}).(pulumi.StringOutput)
+
+	publicKeysPem := pulumi.All(publicKeyPems...).ApplyT(func(args []interface{}) (string, error) {
+		state := RotationState{Generation: args[0].(int), Phase: args[1].(string)}
+		return pickVerificationKeys(generations, state, toStrings(args[2:]))
+	}).(pulumi.StringOutput)
+
+	jwks := pulumi.All(publicKeyPems...).ApplyT(func(args []interface{}) (string, error) {
+		state := RotationState{Generation: args[0].(int), Phase: args[1].(string)}
+		keySet, err := verificationJWKS(generations, state, toStrings(args[2:]))
+		if err != nil {
+			return "", err
+		}
+		return keySet.Marshal()
+	}).(pulumi.StringOutput)
+
+	// the signing key comes first in the verification keys
+	keyIDs := jwks.ApplyT(func(jwks string) (string, error) {
+		keySet, err := oidc.ParseJWKS([]byte(jwks))
+		if err != nil {
+			return "", err
+		}
+		var kids []string
+		for _, key := range keySet.Keys {
+			kids = append(kids, key.Kid)
+		}
+		return strings.Join(kids, ","), nil
+	}).(pulumi.StringOutput)
+
+	// the keys are passed through the environment so that they are never
+	// interpreted by the shell, the command runs again whenever they change
+	files, err := local.NewCommand(c.pulumiContext, fmt.Sprintf("%s-service-account-keys", c.name), &local.CommandArgs{
+		Create: pulumi.String(toWriteKeysCommand(hostDir)),
+		Delete: pulumi.String(toDeleteKeysCommand(hostDir)),
+		Environment: pulumi.StringMap{
+			signingKeyEnv:       signingKeyPem,
+			verificationKeysEnv: publicKeysPem,
+		},
+	}, pulumi.Parent(parent), pulumi.AdditionalSecretOutputs([]string{"environment"}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &signingKeys{
+		hostDir:       hostDir,
+		files:         files,
+		publicKeysPem: publicKeysPem,
+		jwks:          jwks,
+		keyIDs:        keyIDs,
+	}, nil
+}
+
+// restartControlPlane restarts the apiserver and controller manager on every
+// control plane node once the keys change, a new cluster mounts the keys
+// from the start so nothing is run on create
+func (k *signingKeys) restartControlPlane(ctx *pulumi.Context, name, nodeProvider string, clusterName pulumi.StringOutput, parent pulumi.Resource) (pulumi.Resource, error) {
+	return local.NewCommand(ctx, fmt.Sprintf("%s-control-plane-restart", name), &local.CommandArgs{
+		Update: clusterName.ApplyT(func(clusterName string) string {
+			return toRestartControlPlaneCommand(nodeProvider, clusterName)
+		}).(pulumi.StringOutput),
+		Environment: pulumi.StringMap{
+			keyIDsEnv: k.keyIDs,
+		},
+	}, pulumi.Parent(parent), pulumi.DependsOn([]pulumi.Resource{k.files}))
+}
+
+// mounts exposes the keys to the control plane nodes
+func (k *signingKeys) mounts() mount.MountArray {
+	return mount.MountArray{
+		mount.MountArgs{
+			ContainerPath: pulumi.StringPtr(signingKeyNodeDir),
+			HostPath:      pulumi.String(k.hostDir),
+			ReadOnly:      pulumi.BoolPtr(true),
+		},
+	}
+}
+
+// keyOfGeneration picks the key of a generation among the keys of the
+// declared generations
+func keyOfGeneration(generations []int, generation int, pems []string) (string, error) {
+	for i, declared := range generations {
+		if declared == generation {
+			return pems[i], nil
+		}
+	}
+	return "", errors.Errorf("service account key generation %d is not declared", generation)
+}
+
+// pickVerificationKeys joins the public keys tokens are verified with in the
+// phase of the state, the signing key first
+func pickVerificationKeys(generations []int, state RotationState, pems []string) (string, error) {
+	var verificationKeys strings.Builder
+	for _, verificationGeneration := range state.verificationGenerations() {
+		pem, err := keyOfGeneration(generations, verificationGeneration, pems)
+		if err != nil {
+			return "", err
+		}
+		verificationKeys.WriteString(pem)
+	}
+	return verificationKeys.String(), nil
+}
+
+// verificationJWKS merges the JWKS of every key tokens are verified with in
+// the phase of the state, during a rotation it holds both keys
+func verificationJWKS(generations []int, state RotationState, pems []string) (oidc.JSONWebKeySet, error) {
+	var keySets []oidc.JSONWebKeySet
+	for _, verificationGeneration := range state.verificationGenerations() {
+		pem, err := keyOfGeneration(generations, verificationGeneration, pems)
+		if err != nil {
+			return oidc.JSONWebKeySet{}, err
+		}
+		keySet, err := oidc.JWKSFromPublicKeys(pem)
+		if err != nil {
+			return oidc.JSONWebKeySet{}, err
+		}
+		keySets = append(keySets, *keySet)
+	}
+	return oidc.MergeJWKS(keySets...)
+}
+
+func toStrings(values []interface{}) []string {
+	var strs []string
+	for _, value := range values {
+		strs = append(strs, value.(string))
+	}
+	return strs
+}
+
+// signingKeyName keeps the first generation on the name used before
+// rotation was supported
+func signingKeyName(name string, generation int) string {
+	if generation == 0 {
+		return fmt.Sprintf("%s-service-account", name)
+	}
+	return fmt.Sprintf("%s-service-account-%d", name, generation)
+}
+
+func signingKeyHostDir(project, stack, name string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to find a directory to store the service account signing key")
+	}
+	return filepath.Join(cacheDir, "irsa-anywhere", fmt.Sprintf("%s-%s", project, stack), name), nil
+}
+
+// toWriteKeysCommand writes the signing key and the verification keys, which
+// can hold more than one public key
+func toWriteKeysCommand(dir string) string {
+	return strings.Join([]string{
+		fmt.Sprintf("mkdir -p '%s'", dir),
+		fmt.Sprintf("chmod 700 '%s'", dir),
+		fmt.Sprintf("(umask 077 && printf '%%s' \"$%s\" > '%s')", signingKeyEnv, filepath.Join(dir, signingKeyFile)),
+		fmt.Sprintf("printf '%%s' \"$%s\" > '%s'", verificationKeysEnv, filepath.Join(dir, verificationKeyFile)),
+	}, " && ")
+}
+
+func toDeleteKeysCommand(dir string) string {
+	return fmt.Sprintf("rm -f '%s' '%s'", filepath.Join(dir, signingKeyFile), filepath.Join(dir, verificationKeyFile))
+}
+
+// toRestartControlPlaneCommand stops the apiserver and controller manager
+// containers on every control plane node so they load the rewritten keys,
+// kubelet brings them back right away
+func toRestartControlPlaneCommand(nodeProvider, clusterName string) string {
+	if nodeProvider == "" {
+		nodeProvider = NodeProviderDocker
+	}
+	return fmt.Sprintf(`%[1]s ps --quiet --filter label=%[2]s=%[3]s --filter label=%[4]s=control-plane | xargs -r -I{} %[1]s exec {} sh -c "crictl ps --quiet --name 'kube-apiserver|kube-controller-manager' | xargs -r crictl stop"`, nodeProvider, kindClusterLabel, clusterName, kindRoleLabel)
+}
+
+// signingKeyArgs points the apiserver and controller manager to the mounted keys
+func signingKeyArgs() (map[string]string, map[string]string) {
+	apiServerArgs := map[string]string{
+		"service-account-signing-key-file": filepath.Join(signingKeyNodeDir, signingKeyFile),
+		"service-account-key-file":         filepath.Join(signingKeyNodeDir, verificationKeyFile),
+	}
+	controllerManagerArgs := map[string]string{
+		"service-account-private-key-file": filepath.Join(signingKeyNodeDir, signingKeyFile),
+	}
+	return apiServerArgs, controllerManagerArgs
+}
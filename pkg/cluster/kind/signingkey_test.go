@@ -0,0 +1,170 @@
+package kind
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+)
+
+func TestWriteKeysCommand(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	signingKey := "private $HOME \"quoted\" %s"
+	verificationKeys := "public\nnext-public"
+
+	cmd := exec.Command("sh", "-c", toWriteKeysCommand(dir))
+	cmd.Env = append(os.Environ(), signingKeyEnv+"="+signingKey, verificationKeysEnv+"="+verificationKeys)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+
+	for path, expected := range map[string]string{
+		filepath.Join(dir, signingKeyFile):      signingKey,
+		filepath.Join(dir, verificationKeyFile): verificationKeys,
+	} {
+		actual, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(actual) != expected {
+			t.Errorf("expected: %s\n, got: %s\n", expected, actual)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dir, signingKeyFile)); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the signing key to only be readable by its owner, got: %s", info.Mode().Perm())
+	}
+
+	if out, err := exec.Command("sh", "-c", toDeleteKeysCommand(dir)).CombinedOutput(); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, signingKeyFile)); !os.IsNotExist(err) {
+		t.Errorf("expected the signing key to be deleted, got: %v", err)
+	}
+}
+
+func TestPickVerificationKeys(t *testing.T) {
+	generations := declaredKeyGenerations(2)
+	pems := []string{"public-1\n", "public-2\n"}
+
+	tests := []struct {
+		state    RotationState
+		expected string
+	}{
+		{
+			state:    RotationState{Generation: 1, Phase: RotationPhaseStable},
+			expected: "public-1\n",
+		},
+		{
+			state:    RotationState{Generation: 1, Phase: RotationPhaseOverlap},
+			expected: "public-1\npublic-2\n",
+		},
+		{
+			state:    RotationState{Generation: 2, Phase: RotationPhaseSwitched},
+			expected: "public-2\npublic-1\n",
+		},
+	}
+
+	for _, test := range tests {
+		if actual, err := pickVerificationKeys(generations, test.state, pems); err != nil {
+			t.Error(err)
+		} else if actual != test.expected {
+			t.Errorf("%+v expected: %s\n, got: %s\n", test.state, test.expected, actual)
+		}
+	}
+
+	if _, err := keyOfGeneration(generations, 0, pems); err == nil {
+		t.Error("expected an error for a generation that is not declared")
+	}
+}
+
+func testPublicKeyPem(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestVerificationJWKS(t *testing.T) {
+	generations := declaredKeyGenerations(1)
+	pems := []string{testPublicKeyPem(t), testPublicKeyPem(t)}
+
+	for _, state := range []RotationState{
+		{Generation: 0, Phase: RotationPhaseStable},
+		{Generation: 0, Phase: RotationPhaseOverlap},
+		{Generation: 1, Phase: RotationPhaseSwitched},
+	} {
+		// the published JWKS matches the keys the apiserver verifies with
+		publicKeysPem, err := pickVerificationKeys(generations, state, pems)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := oidc.JWKSFromPublicKeys(publicKeysPem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual, err := verificationJWKS(generations, state, pems); err != nil {
+			t.Error(err)
+		} else if !actual.Equal(*expected) || len(actual.Keys) != len(state.verificationGenerations()) {
+			t.Errorf("%+v expected: %+v\n, got: %+v\n", state, expected.Keys, actual.Keys)
+		}
+	}
+}
+
+func TestRestartControlPlaneCommand(t *testing.T) {
+	expected := `podman ps --quiet --filter label=io.x-k8s.kind.cluster=kind-aws --filter label=io.x-k8s.kind.role=control-plane | xargs -r -I{} podman exec {} sh -c "crictl ps --quiet --name 'kube-apiserver|kube-controller-manager' | xargs -r crictl stop"`
+	if actual := toRestartControlPlaneCommand(NodeProviderPodman, "kind-aws"); actual != expected {
+		t.Errorf("expected: %s\n, got: %s\n", expected, actual)
+	}
+}
+
+func TestSigningKeyName(t *testing.T) {
+	if name := signingKeyName("kind-aws", 0); name != "kind-aws-service-account" {
+		t.Errorf("expected the first generation to keep its name, got: %s", name)
+	}
+	if name := signingKeyName("kind-aws", 2); name != "kind-aws-service-account-2" {
+		t.Errorf("expected: kind-aws-service-account-2, got: %s", name)
+	}
+}
+
+func TestNewSigningKey(t *testing.T) {
+	if signingKey, err := NewSigningKey("", 1, ""); err != nil || signingKey != nil {
+		t.Errorf("expected no signing key without an algorithm, got: %+v, %v", signingKey, err)
+	}
+
+	signingKey, err := NewSigningKey(SigningKeyAlgorithmECDSA, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signingKey.MaxTokenLifetime != defaultMaxTokenLifetime {
+		t.Errorf("expected: %s\n, got: %s\n", defaultMaxTokenLifetime, signingKey.MaxTokenLifetime)
+	}
+
+	for _, args := range []struct {
+		algorithm        string
+		generation       int
+		maxTokenLifetime string
+	}{
+		{algorithm: "ED25519"},
+		{algorithm: SigningKeyAlgorithmRSA, generation: -1},
+		{algorithm: SigningKeyAlgorithmRSA, maxTokenLifetime: "a day"},
+	} {
+		if _, err := NewSigningKey(args.algorithm, args.generation, args.maxTokenLifetime); err == nil {
+			t.Errorf("expected %+v to be rejected", args)
+		}
+	}
+}
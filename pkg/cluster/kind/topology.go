@@ -0,0 +1,149 @@
+package kind
+
+import (
+	"encoding/json"
+
+	"github.com/frezbo/pulumi-provider-kind/sdk/v3/go/kind/mount"
+	"github.com/frezbo/pulumi-provider-kind/sdk/v3/go/kind/node"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeadmv1beta2 "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta2"
+	kubeadmv1beta3 "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta3"
+	kubeadmconstants "k8s.io/kubernetes/cmd/kubeadm/app/constants"
+)
+
+// DefaultTopology is a single control plane node without any workers
+func DefaultTopology() *Topology {
+	return &Topology{
+		ControlPlane: NodeGroup{
+			Count: 1,
+		},
+	}
+}
+
+func (t *Topology) validate() error {
+	if t.ControlPlane.Count < 1 {
+		return errors.Errorf("at least one control plane node is required, got: %d", t.ControlPlane.Count)
+	}
+	if t.Worker.Count < 0 {
+		return errors.Errorf("worker count cannot be negative, got: %d", t.Worker.Count)
+	}
+	return nil
+}
+
+// toNodes expands the topology into kind nodes, every control plane node gets
+// the issuer patch and mounts so that any of them can serve as the apiserver
+func (t *Topology) toNodes(issuerPatch pulumi.StringInput, controlPlaneMounts mount.MountArray, image, kubeadmAPIVersion string) (node.NodeArray, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+
+	controlPlanePatches, err := toTaintPatches(t.ControlPlane.Taints, kubeadmAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	workerPatches, err := toTaintPatches(t.Worker.Taints, kubeadmAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeImage pulumi.StringPtrInput
+	if image != "" {
+		nodeImage = pulumi.StringPtr(image)
+	}
+
+	var extraMounts mount.MountArrayInput
+	if len(controlPlaneMounts) > 0 {
+		extraMounts = controlPlaneMounts
+	}
+
+	nodes := node.NodeArray{}
+	for i := 0; i < t.ControlPlane.Count; i++ {
+		nodes = append(nodes, node.NodeArgs{
+			Role:                 node.RoleTypeControlPlane,
+			Image:                nodeImage,
+			Labels:               pulumi.ToStringMap(t.ControlPlane.Labels),
+			ExtraMounts:          extraMounts,
+			KubeadmConfigPatches: append(pulumi.StringArray{issuerPatch}, pulumi.ToStringArray(controlPlanePatches)...),
+		})
+	}
+	for i := 0; i < t.Worker.Count; i++ {
+		nodes = append(nodes, node.NodeArgs{
+			Role:                 node.RoleTypeWorker,
+			Image:                nodeImage,
+			Labels:               pulumi.ToStringMap(t.Worker.Labels),
+			KubeadmConfigPatches: pulumi.ToStringArray(workerPatches),
+		})
+	}
+	return nodes, nil
+}
+
+// toTaintPatches sets the taints for both the first control plane node
+// (InitConfiguration) and every other node joining the cluster (JoinConfiguration)
+func toTaintPatches(taints []corev1.Taint, kubeadmAPIVersion string) ([]string, error) {
+	if len(taints) == 0 {
+		return []string{}, nil
+	}
+
+	var initConfig, joinConfig interface{}
+	switch kubeadmAPIVersion {
+	case kubeadmV1beta2:
+		nodeRegistration := kubeadmv1beta2.NodeRegistrationOptions{
+			Taints: taints,
+		}
+		initConfig = &kubeadmv1beta2.InitConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				Kind: kubeadmconstants.InitConfigurationKind,
+			},
+			NodeRegistration: nodeRegistration,
+		}
+		joinConfig = &kubeadmv1beta2.JoinConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				Kind: kubeadmconstants.JoinConfigurationKind,
+			},
+			NodeRegistration: nodeRegistration,
+		}
+	case kubeadmV1beta3:
+		nodeRegistration := kubeadmv1beta3.NodeRegistrationOptions{
+			Taints: taints,
+		}
+		initConfig = &kubeadmv1beta3.InitConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				Kind: kubeadmconstants.InitConfigurationKind,
+			},
+			NodeRegistration: nodeRegistration,
+		}
+		joinConfig = &kubeadmv1beta3.JoinConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				Kind: kubeadmconstants.JoinConfigurationKind,
+			},
+			NodeRegistration: nodeRegistration,
+		}
+	case kubeadmV1beta4:
+		nodeRegistration := nodeRegistrationOptionsV1beta4{
+			Taints: taints,
+		}
+		initConfig = &initConfigurationV1beta4{
+			Kind:             kubeadmconstants.InitConfigurationKind,
+			NodeRegistration: nodeRegistration,
+		}
+		joinConfig = &joinConfigurationV1beta4{
+			Kind:             kubeadmconstants.JoinConfigurationKind,
+			NodeRegistration: nodeRegistration,
+		}
+	default:
+		return nil, errors.Errorf("unsupported kubeadm api version: %s", kubeadmAPIVersion)
+	}
+
+	initConfigBytes, err := json.Marshal(initConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal kubeadm init config yaml")
+	}
+	joinConfigBytes, err := json.Marshal(joinConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal kubeadm join config yaml")
+	}
+	return []string{string(initConfigBytes), string(joinConfigBytes)}, nil
+}
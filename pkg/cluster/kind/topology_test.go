@@ -0,0 +1,51 @@
+package kind
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestTaintPatchesGolden(t *testing.T) {
+	taints := []corev1.Taint{
+		{
+			Key:    "dedicated",
+			Value:  "controllers",
+			Effect: corev1.TaintEffectNoSchedule,
+		},
+	}
+
+	for _, kubeadmAPIVersion := range []string{kubeadmV1beta2, kubeadmV1beta3, kubeadmV1beta4} {
+		goldenName := fmt.Sprintf("taint-patches-%s.json", filepath.Base(kubeadmAPIVersion))
+		golden, err := os.ReadFile(filepath.Join("testdata", goldenName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := strings.Split(strings.TrimSpace(string(golden)), "\n")
+
+		if actual, err := toTaintPatches(taints, kubeadmAPIVersion); err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: expected: %s\n, got: %s\n", goldenName, expected, actual)
+		}
+	}
+
+	if _, err := toTaintPatches(taints, "kubeadm.k8s.io/v1beta1"); err == nil {
+		t.Error("expected an error for an unsupported kubeadm api version")
+	}
+}
+
+func TestTopologyValidate(t *testing.T) {
+	if err := DefaultTopology().validate(); err != nil {
+		t.Error(err)
+	}
+
+	if err := (&Topology{Worker: NodeGroup{Count: 2}}).validate(); err == nil {
+		t.Error("expected an error for a topology without control plane nodes")
+	}
+}
@@ -1,8 +1,67 @@
 package kind
 
-import "github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+import (
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	NodeProviderDocker = "docker"
+	NodeProviderPodman = "podman"
+	// NodeProviderNerdctl is supported by kind but not by the pulumi kind
+	// provider, so it is rejected
+	NodeProviderNerdctl = "nerdctl"
+)
+
+// supportedNodeProviders are the runtimes the pulumi kind provider creates
+// clusters with
+var supportedNodeProviders = []string{NodeProviderDocker, NodeProviderPodman}
 
 type kindConfig struct {
 	pulumiContext *pulumi.Context
 	name          string
+	topology      *Topology
+	nodeImage     *NodeImage
+	nodeProvider  string
+	// signingKey is nil when kubeadm generates the service account signing key
+	signingKey *SigningKey
+}
+
+// SigningKey configures the service account signing key managed by the stack
+type SigningKey struct {
+	// Algorithm is either RSA or ECDSA
+	Algorithm string
+	// Generation is the desired key generation, bumping it rotates the key
+	Generation int
+	// MaxTokenLifetime is how long the previous key stays published after
+	// the apiserver switched to signing with the next key
+	MaxTokenLifetime time.Duration
+}
+
+// NodeImage pins the kubernetes version of the kind nodes, read from the
+// `kindNodeImage` and `kubernetesVersion` stack config
+type NodeImage struct {
+	// Image is the node image to use, its tag is used as the kubernetes
+	// version unless KubernetesVersion is set
+	Image string
+	// KubernetesVersion selects the `kindest/node` image of the version
+	// when Image is not set
+	KubernetesVersion string
+}
+
+// Topology describes the nodes of the kind cluster, read from the
+// `kindTopology` stack config
+type Topology struct {
+	ControlPlane NodeGroup `json:"controlPlane"`
+	Worker       NodeGroup `json:"worker"`
+}
+
+// NodeGroup is a set of identical nodes sharing the same role
+type NodeGroup struct {
+	Count  int               `json:"count"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Taints replace the default taints kubeadm sets on the nodes
+	Taints []corev1.Taint `json:"taints,omitempty"`
 }
@@ -0,0 +1,79 @@
+package kind
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	kubeadmv1beta2 "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta2"
+	kubeadmv1beta3 "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta3"
+)
+
+const (
+	nodeImageRepository = "kindest/node"
+)
+
+var (
+	kubeadmV1beta2 = kubeadmv1beta2.SchemeGroupVersion.String()
+	kubeadmV1beta3 = kubeadmv1beta3.SchemeGroupVersion.String()
+	// kubeadmV1beta4 is newer than the vendored kubeadm API
+	kubeadmV1beta4 = "kubeadm.k8s.io/v1beta4"
+
+	// v1beta2 is the oldest kubeadm API kind still supports, kind switched
+	// to generating v1beta3 configs starting from v1.23 and v1beta4 configs
+	// starting from v1.31
+	minKubernetesVersion     = version.MustParseGeneric("v1.15.0")
+	kubeadmV1beta3MinVersion = version.MustParseGeneric("v1.23.0")
+	kubeadmV1beta4MinVersion = version.MustParseGeneric("v1.31.0")
+)
+
+// resolve returns the node image to use along with the kubeadm API version
+// matching its kubernetes version, when nothing is pinned the kind default
+// image is used with the v1beta2 API
+func (n *NodeImage) resolve() (string, string, error) {
+	if n.Image == "" && n.KubernetesVersion == "" {
+		return "", kubeadmV1beta2, nil
+	}
+
+	image := n.Image
+	kubernetesVersion := n.KubernetesVersion
+	if image == "" {
+		image = fmt.Sprintf("%s:v%s", nodeImageRepository, strings.TrimPrefix(kubernetesVersion, "v"))
+	}
+	if kubernetesVersion == "" {
+		kubernetesVersion = imageTag(image)
+	}
+
+	kubeadmAPIVersion, err := kubeadmAPIVersionFor(kubernetesVersion)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "unable to find the kubernetes version of node image %s, set kubernetesVersion explicitly", image)
+	}
+	return image, kubeadmAPIVersion, nil
+}
+
+func kubeadmAPIVersionFor(kubernetesVersion string) (string, error) {
+	v, err := version.ParseGeneric(kubernetesVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse kubernetes version: %s", kubernetesVersion)
+	}
+	if v.LessThan(minKubernetesVersion) {
+		return "", errors.Errorf("kubernetes version %s is not supported, minimum supported version is %s", kubernetesVersion, minKubernetesVersion)
+	}
+	if v.LessThan(kubeadmV1beta3MinVersion) {
+		return kubeadmV1beta2, nil
+	}
+	if v.LessThan(kubeadmV1beta4MinVersion) {
+		return kubeadmV1beta3, nil
+	}
+	return kubeadmV1beta4, nil
+}
+
+// imageTag returns the tag of an image reference, ignoring any digest
+func imageTag(image string) string {
+	image = strings.SplitN(image, "@", 2)[0]
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return ""
+}
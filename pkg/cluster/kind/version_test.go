@@ -0,0 +1,69 @@
+package kind
+
+import (
+	"testing"
+)
+
+func TestNodeImageResolve(t *testing.T) {
+	tests := []struct {
+		nodeImage         NodeImage
+		image             string
+		kubeadmAPIVersion string
+	}{
+		{
+			nodeImage:         NodeImage{},
+			image:             "",
+			kubeadmAPIVersion: kubeadmV1beta2,
+		},
+		{
+			nodeImage:         NodeImage{KubernetesVersion: "1.21.14"},
+			image:             "kindest/node:v1.21.14",
+			kubeadmAPIVersion: kubeadmV1beta2,
+		},
+		{
+			nodeImage:         NodeImage{KubernetesVersion: "v1.27.3"},
+			image:             "kindest/node:v1.27.3",
+			kubeadmAPIVersion: kubeadmV1beta3,
+		},
+		{
+			nodeImage:         NodeImage{Image: "kindest/node:v1.25.3@sha256:f52781bc0d7a19fb6c405c2af83abfeb311f130707a0e219175677e366cc45d1"},
+			image:             "kindest/node:v1.25.3@sha256:f52781bc0d7a19fb6c405c2af83abfeb311f130707a0e219175677e366cc45d1",
+			kubeadmAPIVersion: kubeadmV1beta3,
+		},
+		{
+			nodeImage:         NodeImage{KubernetesVersion: "1.31.0"},
+			image:             "kindest/node:v1.31.0",
+			kubeadmAPIVersion: kubeadmV1beta4,
+		},
+		{
+			nodeImage:         NodeImage{Image: "kindest/node:v1.30.4"},
+			image:             "kindest/node:v1.30.4",
+			kubeadmAPIVersion: kubeadmV1beta3,
+		},
+		{
+			nodeImage:         NodeImage{Image: "localhost:5000/node:custom", KubernetesVersion: "1.22.0"},
+			image:             "localhost:5000/node:custom",
+			kubeadmAPIVersion: kubeadmV1beta2,
+		},
+	}
+
+	for _, test := range tests {
+		image, kubeadmAPIVersion, err := test.nodeImage.resolve()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if image != test.image || kubeadmAPIVersion != test.kubeadmAPIVersion {
+			t.Errorf("expected: %s %s\n, got: %s %s\n", test.image, test.kubeadmAPIVersion, image, kubeadmAPIVersion)
+		}
+	}
+
+	for _, nodeImage := range []NodeImage{
+		{Image: "localhost:5000/node:custom"},
+		{KubernetesVersion: "1.14.10"},
+	} {
+		if _, _, err := nodeImage.resolve(); err == nil {
+			t.Errorf("expected an error for %+v", nodeImage)
+		}
+	}
+}
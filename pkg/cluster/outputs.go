@@ -0,0 +1,13 @@
+package cluster
+
+// stack outputs downstream stacks read through a StackReference
+const (
+	IssuerURLOutput        = "issuerUrl"
+	OIDCProviderARNOutput  = "oidcProviderArn"
+	IssuerBucketOutput     = "issuerBucket"
+	AudienceOutput         = "audience"
+	AnnotationPrefixOutput = "annotationPrefix"
+	WebhookNamespaceOutput = "webhookNamespace"
+	ClusterNameOutput      = "clusterName"
+	KubeconfigOutput       = "kubeconfig"
+)
@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"reflect"
+
+	"github.com/frezbo/irsa-anywhere/pkg/component"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func init() {
+	pulumi.RegisterOutputType(OIDCDocumentsOutput{})
+}
+
+// ClusterBackend provisions a kubernetes cluster whose apiserver uses the
+// given service account issuer
+type ClusterBackend interface {
+	// Create creates the cluster resources under parent
+	Create(issuer *Issuer, parent pulumi.Resource) (*Cluster, error)
+}
+
+// IssuerHost hosts the issuer documents AWS fetches when exchanging tokens
+type IssuerHost interface {
+	// Create provisions the hosting and returns the issuer url, the cluster
+	// is created with it before any document is published
+	Create(parent *component.Issuer) (pulumi.StringOutput, error)
+	// Publish hosts the validated documents served by the cluster
+	Publish(documents OIDCDocumentsOutput) error
+}
+
+// IssuerBucketHost is an IssuerHost publishing the documents to an S3 bucket
+type IssuerBucketHost interface {
+	IssuerHost
+	// Bucket is the name of the bucket, set once the hosting is created
+	Bucket() pulumi.StringOutput
+}
+
+// ClusterConfig creates the cluster and everything IRSA needs around it
+type ClusterConfig interface {
+	Create() (*Outputs, error)
+}
+
+// Outputs are what application stacks need to use IRSA with the cluster
+type Outputs struct {
+	Cluster *component.Cluster
+	Webhook *component.Webhook
+	// IssuerBucket is nil unless the documents are hosted in an S3 bucket
+	IssuerBucket pulumi.StringInput
+}
+
+// Issuer describes where the service account issuer documents are hosted
+type Issuer struct {
+	// URL is the issuer url, `https://<host>[/<path>]`
+	URL pulumi.StringOutput
+	// Audiences are the audiences the apiserver issues tokens for
+	Audiences []string
+}
+
+// Cluster is what a backend hands back once the cluster is created
+type Cluster struct {
+	Resource pulumi.Resource
+	// Name is the name of the cluster as known to its tooling
+	Name       pulumi.StringOutput
+	Kubeconfig pulumi.StringOutput
+	// OIDCConfig holds the discovery and JWKS documents served by the cluster
+	OIDCConfig OIDCDocumentsOutput
+}
+
+// OIDCDocumentsOutput is the output of appliers returning `oidc.Documents`
+type OIDCDocumentsOutput struct{ *pulumi.OutputState }
+
+func (OIDCDocumentsOutput) ElementType() reflect.Type {
+	return reflect.TypeOf((*oidc.Documents)(nil)).Elem()
+}
+
+// DiscoveryJSON is the discovery document the way the apiserver serves it
+func (o OIDCDocumentsOutput) DiscoveryJSON() pulumi.StringOutput {
+	return o.ApplyT(func(documents oidc.Documents) (string, error) {
+		return documents.Discovery.Marshal()
+	}).(pulumi.StringOutput)
+}
+
+// JWKSJSON is the JWKS the way the apiserver serves it
+func (o OIDCDocumentsOutput) JWKSJSON() pulumi.StringOutput {
+	return o.ApplyT(func(documents oidc.Documents) (string, error) {
+		return documents.JWKS.Marshal()
+	}).(pulumi.StringOutput)
+}
+
+type clusterConfig struct {
+	pulumiContext *pulumi.Context
+	name          string
+	backend       ClusterBackend
+	issuerHost    IssuerHost
+	audiences     *Audiences
+}
@@ -6,12 +6,141 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
-func NewDynamicComponent(ctx *pulumi.Context, name string, opts ...pulumi.ResourceOption) (*DynamicComponent, error) {
-	dynamicComponent := &DynamicComponent{}
-	componentToken := fmt.Sprintf("irsa:cluster:%s", name)
-	err := ctx.RegisterComponentResource(componentToken, name, dynamicComponent, opts...)
-	if err != nil {
+// legacyClusterType is the type token every component used to be registered
+// with, one per cluster name
+func legacyClusterType(clusterName string) string {
+	return fmt.Sprintf("irsa:cluster:%s", clusterName)
+}
+
+// NewCluster registers the cluster component, aliased to the legacy
+// per-cluster type so that existing stacks keep their resources
+func NewCluster(ctx *pulumi.Context, name string, args *ClusterArgs, opts ...pulumi.ResourceOption) (*Cluster, error) {
+	cluster := &Cluster{legacyName: name}
+	opts = append(opts, pulumi.Aliases([]pulumi.Alias{{Type: pulumi.String(legacyClusterType(name))}}))
+	if err := ctx.RegisterComponentResource(ClusterType, name, cluster, opts...); err != nil {
+		return nil, err
+	}
+	cluster.Audiences = pulumi.ToStringArray(args.Audiences).ToStringArrayOutput()
+	return cluster, nil
+}
+
+// RegisterOutputs sets the outputs once every child is created
+func (c *Cluster) RegisterOutputs(ctx *pulumi.Context, issuerURL, oidcProviderARN, clusterName, kubeconfig pulumi.StringOutput) error {
+	c.IssuerURL = issuerURL
+	c.OIDCProviderARN = oidcProviderARN
+	c.ClusterName = clusterName
+	c.Kubeconfig = pulumi.ToSecret(kubeconfig).(pulumi.StringOutput)
+	return ctx.RegisterResourceOutputs(c, pulumi.Map{
+		"audiences":       c.Audiences,
+		"issuerUrl":       c.IssuerURL,
+		"oidcProviderArn": c.OIDCProviderARN,
+		"clusterName":     c.ClusterName,
+		"kubeconfig":      c.Kubeconfig,
+	})
+}
+
+// NewIssuer registers the issuer component under the cluster
+func NewIssuer(ctx *pulumi.Context, name string, args *IssuerArgs, cluster *Cluster, opts ...pulumi.ResourceOption) (*Issuer, error) {
+	issuer := &Issuer{}
+	if err := registerChild(ctx, IssuerType, name, issuer, cluster, opts); err != nil {
 		return nil, err
 	}
-	return dynamicComponent, nil
+	issuer.ClientIDs = pulumi.ToStringArray(args.ClientIDs).ToStringArrayOutput()
+	return issuer, nil
+}
+
+// RegisterOutputs sets the outputs once the hosting and the AWS IAM OIDC
+// provider are created
+func (c *Issuer) RegisterOutputs(ctx *pulumi.Context, url, oidcProviderARN pulumi.StringOutput) error {
+	c.URL = url
+	c.OIDCProviderARN = oidcProviderARN
+	return ctx.RegisterResourceOutputs(c, pulumi.Map{
+		"clientIds":       c.ClientIDs,
+		"url":             c.URL,
+		"oidcProviderArn": c.OIDCProviderARN,
+	})
+}
+
+// NewWebhook registers the webhook component under the cluster
+func NewWebhook(ctx *pulumi.Context, name string, args *WebhookArgs, cluster *Cluster, opts ...pulumi.ResourceOption) (*Webhook, error) {
+	webhook := &Webhook{}
+	if err := registerChild(ctx, WebhookType, name, webhook, cluster, opts); err != nil {
+		return nil, err
+	}
+	webhook.Namespace = pulumi.String(args.Namespace).ToStringOutput()
+	webhook.AnnotationPrefix = pulumi.String(args.AnnotationPrefix).ToStringOutput()
+	webhook.Audience = pulumi.String(args.Audience).ToStringOutput()
+	return webhook, nil
+}
+
+// RegisterOutputs registers the outputs once the webhook is deployed
+func (c *Webhook) RegisterOutputs(ctx *pulumi.Context) error {
+	return ctx.RegisterResourceOutputs(c, pulumi.Map{
+		"namespace":        c.Namespace,
+		"annotationPrefix": c.AnnotationPrefix,
+		"audience":         c.Audience,
+	})
+}
+
+// NewWorkloadRole registers the workload role component under the cluster
+func NewWorkloadRole(ctx *pulumi.Context, name string, args *WorkloadRoleArgs, cluster *Cluster, opts ...pulumi.ResourceOption) (*WorkloadRole, error) {
+	workloadRole := &WorkloadRole{}
+	if err := registerChild(ctx, WorkloadRoleType, name, workloadRole, cluster, opts); err != nil {
+		return nil, err
+	}
+	workloadRole.Namespace = pulumi.String(args.Namespace).ToStringOutput()
+	workloadRole.ServiceAccount = pulumi.String(args.ServiceAccount).ToStringOutput()
+	return workloadRole, nil
+}
+
+// RegisterOutputs sets the outputs once the role is created
+func (c *WorkloadRole) RegisterOutputs(ctx *pulumi.Context, roleARN pulumi.StringOutput) error {
+	c.RoleARN = roleARN
+	return ctx.RegisterResourceOutputs(c, pulumi.Map{
+		"roleArn":        c.RoleARN,
+		"namespace":      c.Namespace,
+		"serviceAccount": c.ServiceAccount,
+	})
+}
+
+// registerChild registers a component under the cluster. Its children used to
+// be children of the legacy cluster component, the parentless legacy type
+// alias is inherited by them as their previous urn. A nil cluster registers a
+// standalone component, as constructed by the provider
+func registerChild(ctx *pulumi.Context, t, name string, resource pulumi.ComponentResource, cluster *Cluster, opts []pulumi.ResourceOption) error {
+	if cluster == nil {
+		return ctx.RegisterComponentResource(t, name, resource, opts...)
+	}
+	opts = append(opts,
+		pulumi.Parent(cluster),
+		pulumi.Aliases([]pulumi.Alias{{
+			Type:     pulumi.String(legacyClusterType(cluster.legacyName)),
+			NoParent: pulumi.Bool(true),
+		}}),
+	)
+	return ctx.RegisterComponentResource(t, name, resource, opts...)
+}
+
+// NewKindCluster registers a kind cluster constructed on its own by the
+// provider, the program creates it under the Cluster component instead
+func NewKindCluster(ctx *pulumi.Context, name string, opts ...pulumi.ResourceOption) (*KindCluster, error) {
+	kindCluster := &KindCluster{}
+	if err := ctx.RegisterComponentResource(KindClusterType, name, kindCluster, opts...); err != nil {
+		return nil, err
+	}
+	return kindCluster, nil
+}
+
+// RegisterOutputs sets the outputs once the cluster serves its documents
+func (c *KindCluster) RegisterOutputs(ctx *pulumi.Context, clusterName, kubeconfig, discovery, jwks pulumi.StringOutput) error {
+	c.ClusterName = clusterName
+	c.Kubeconfig = pulumi.ToSecret(kubeconfig).(pulumi.StringOutput)
+	c.Discovery = discovery
+	c.JWKS = jwks
+	return ctx.RegisterResourceOutputs(c, pulumi.Map{
+		"clusterName": c.ClusterName,
+		"kubeconfig":  c.Kubeconfig,
+		"discovery":   c.Discovery,
+		"jwks":        c.JWKS,
+	})
 }
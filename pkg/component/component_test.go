@@ -0,0 +1,70 @@
+package component
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type aliasMocks struct {
+	lock    sync.Mutex
+	aliases map[string][]string
+}
+
+func (m *aliasMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.aliases[args.TypeToken+"::"+args.Name] = args.RegisterRPC.GetAliases()
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (m *aliasMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestLegacyAliases(t *testing.T) {
+	mocks := &aliasMocks{aliases: map[string][]string{}}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		cluster, err := NewCluster(ctx, "kind-aws", &ClusterArgs{})
+		if err != nil {
+			return err
+		}
+		issuer, err := NewIssuer(ctx, "kind-aws-issuer", &IssuerArgs{}, cluster)
+		if err != nil {
+			return err
+		}
+		child := &pulumi.ResourceState{}
+		return ctx.RegisterComponentResource("test:index:Child", "kind-aws", child, pulumi.Parent(issuer))
+	}, pulumi.WithMocks("project", "stack", mocks))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		resource string
+		alias    string
+	}{
+		{
+			resource: ClusterType + "::kind-aws",
+			alias:    "urn:pulumi:stack::project::irsa:cluster:kind-aws::kind-aws",
+		},
+		{
+			resource: "test:index:Child::kind-aws",
+			alias:    "urn:pulumi:stack::project::irsa:cluster:kind-aws$test:index:Child::kind-aws",
+		},
+	}
+
+	for _, tt := range tests {
+		found := false
+		for _, alias := range mocks.aliases[tt.resource] {
+			if alias == tt.alias {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected: %s\n, got: %s\n", tt.alias, mocks.aliases[tt.resource])
+		}
+	}
+}
@@ -2,6 +2,90 @@ package component
 
 import "github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 
-type DynamicComponent struct {
+const (
+	// Version is part of every type token, a breaking change to a component
+	// gets a new version aliased to the previous one
+	Version = "v1"
+
+	ClusterType      = "irsa:" + Version + ":Cluster"
+	IssuerType       = "irsa:" + Version + ":Issuer"
+	WebhookType      = "irsa:" + Version + ":Webhook"
+	WorkloadRoleType = "irsa:" + Version + ":WorkloadRole"
+	KindClusterType  = "irsa:" + Version + ":KindCluster"
+)
+
+// Cluster groups a cluster with its issuer, AWS IAM OIDC provider and webhook
+type Cluster struct {
+	pulumi.ResourceState
+	// legacyName is the name of the legacy component type aliased by children
+	legacyName string
+
+	Audiences       pulumi.StringArrayOutput `pulumi:"audiences"`
+	IssuerURL       pulumi.StringOutput      `pulumi:"issuerUrl"`
+	OIDCProviderARN pulumi.StringOutput      `pulumi:"oidcProviderArn"`
+	ClusterName     pulumi.StringOutput      `pulumi:"clusterName"`
+	Kubeconfig      pulumi.StringOutput      `pulumi:"kubeconfig"`
+}
+
+// ClusterArgs are the inputs of a Cluster
+type ClusterArgs struct {
+	// Audiences the apiserver issues tokens for
+	Audiences []string
+}
+
+// Issuer groups the resources hosting the issuer documents with the AWS IAM
+// OIDC provider trusting them
+type Issuer struct {
+	pulumi.ResourceState
+
+	ClientIDs       pulumi.StringArrayOutput `pulumi:"clientIds"`
+	URL             pulumi.StringOutput      `pulumi:"url"`
+	OIDCProviderARN pulumi.StringOutput      `pulumi:"oidcProviderArn"`
+}
+
+// IssuerArgs are the inputs of an Issuer
+type IssuerArgs struct {
+	// ClientIDs are the audiences the AWS IAM OIDC provider accepts
+	ClientIDs []string
+}
+
+// Webhook groups the pod identity webhook deployed into the cluster
+type Webhook struct {
 	pulumi.ResourceState
+
+	Namespace        pulumi.StringOutput `pulumi:"namespace"`
+	AnnotationPrefix pulumi.StringOutput `pulumi:"annotationPrefix"`
+	Audience         pulumi.StringOutput `pulumi:"audience"`
+}
+
+// WebhookArgs are the inputs of a Webhook
+type WebhookArgs struct {
+	Namespace        string
+	AnnotationPrefix string
+	Audience         string
+}
+
+// WorkloadRole groups an AWS IAM role with the service account assuming it
+type WorkloadRole struct {
+	pulumi.ResourceState
+
+	RoleARN        pulumi.StringOutput `pulumi:"roleArn"`
+	Namespace      pulumi.StringOutput `pulumi:"namespace"`
+	ServiceAccount pulumi.StringOutput `pulumi:"serviceAccount"`
+}
+
+// WorkloadRoleArgs are the inputs of a WorkloadRole
+type WorkloadRoleArgs struct {
+	Namespace      string
+	ServiceAccount string
+}
+
+// KindCluster is a kind cluster with the issuer documents it serves
+type KindCluster struct {
+	pulumi.ResourceState
+
+	ClusterName pulumi.StringOutput `pulumi:"clusterName"`
+	Kubeconfig  pulumi.StringOutput `pulumi:"kubeconfig"`
+	Discovery   pulumi.StringOutput `pulumi:"discovery"`
+	JWKS        pulumi.StringOutput `pulumi:"jwks"`
 }
@@ -0,0 +1,88 @@
+package bucket
+
+import (
+	awsmeta "github.com/frezbo/irsa-anywhere/pkg/aws/meta"
+	irsacluster "github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/component"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// NewBucketConfig hosts the issuer documents in an S3 bucket served at the
+// bucket's regional domain, only the documents are readable anonymously
+func NewBucketConfig(ctx *pulumi.Context, name string) irsacluster.IssuerBucketHost {
+	return &bucketConfig{
+		pulumiContext: ctx,
+		name:          name,
+	}
+}
+
+func (c *bucketConfig) Create(parent *component.Issuer) (pulumi.StringOutput, error) {
+	tags, err := awsmeta.ResourceTags(c.pulumiContext, c.name)
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	c.tags = tags
+
+	bucket, ownershipControls, err := NewIssuerBucket(c.pulumiContext, c.name, tags, pulumi.Parent(parent))
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	c.bucket = bucket
+
+	// ACLs stay blocked, the bucket policy is the only public grant
+	publicAccessBlock, err := s3.NewBucketPublicAccessBlock(c.pulumiContext, c.name, &s3.BucketPublicAccessBlockArgs{
+		Bucket:                bucket.ID(),
+		BlockPublicAcls:       pulumi.Bool(true),
+		IgnorePublicAcls:      pulumi.Bool(true),
+		BlockPublicPolicy:     pulumi.Bool(false),
+		RestrictPublicBuckets: pulumi.Bool(false),
+	}, pulumi.Parent(bucket), pulumi.DependsOn([]pulumi.Resource{ownershipControls}))
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+
+	bucketPolicyDocument := bucket.Arn.ApplyT(func(bucketArn string) (string, error) {
+		bucketPolicy, err := iam.GetPolicyDocument(c.pulumiContext, &iam.GetPolicyDocumentArgs{
+			Statements: []iam.GetPolicyDocumentStatement{
+				{
+					Sid:     &[]string{"allowPublicGetIssuerDocuments"}[0],
+					Actions: []string{"s3:GetObject"},
+					Principals: []iam.GetPolicyDocumentStatementPrincipal{
+						{
+							Type:        "*",
+							Identifiers: []string{"*"},
+						},
+					},
+					Resources: documentARNs(bucketArn),
+				},
+			},
+		}, pulumi.Parent(parent))
+		if err != nil {
+			return "", err
+		}
+		return bucketPolicy.Json, nil
+	})
+
+	// a public policy is rejected until the public access block allows it
+	bucketPolicy, err := s3.NewBucketPolicy(c.pulumiContext, c.name, &s3.BucketPolicyArgs{
+		Bucket: bucket.ID(),
+		Policy: bucketPolicyDocument,
+	}, pulumi.Parent(bucket), pulumi.DependsOn([]pulumi.Resource{publicAccessBlock}))
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	c.bucketPolicy = bucketPolicy
+
+	return pulumi.Sprintf("https://%s", bucket.BucketRegionalDomainName), nil
+}
+
+// Bucket is the name of the bucket the documents are published to
+func (c *bucketConfig) Bucket() pulumi.StringOutput {
+	return c.bucket.Bucket
+}
+
+func (c *bucketConfig) Publish(documents irsacluster.OIDCDocumentsOutput) error {
+	return PublishDocuments(c.pulumiContext, c.name, c.bucket, documents, c.tags, pulumi.DependsOn([]pulumi.Resource{c.bucketPolicy}))
+}
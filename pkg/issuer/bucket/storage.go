@@ -0,0 +1,97 @@
+package bucket
+
+import (
+	"fmt"
+	"strings"
+
+	irsacluster "github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	documentContentType = "application/json"
+	// noncurrentVersionDays keeps replaced documents around long enough to
+	// roll back a bad key rotation
+	noncurrentVersionDays = 30
+)
+
+// NewIssuerBucket creates a versioned bucket for the issuer documents with
+// ACLs disabled through enforced bucket ownership and SSE-S3 encryption,
+// KMS encrypted objects cannot be read anonymously or through CloudFront
+func NewIssuerBucket(ctx *pulumi.Context, name string, tags pulumi.StringMap, opts ...pulumi.ResourceOption) (*s3.Bucket, pulumi.Resource, error) {
+	bucket, err := s3.NewBucket(ctx, name, &s3.BucketArgs{
+		ServerSideEncryptionConfiguration: s3.BucketServerSideEncryptionConfigurationArgs{
+			Rule: s3.BucketServerSideEncryptionConfigurationRuleArgs{
+				ApplyServerSideEncryptionByDefault: s3.BucketServerSideEncryptionConfigurationRuleApplyServerSideEncryptionByDefaultArgs{
+					SseAlgorithm: pulumi.String("AES256"),
+				},
+			},
+		},
+		Versioning: s3.BucketVersioningArgs{
+			Enabled: pulumi.Bool(true),
+		},
+		LifecycleRules: s3.BucketLifecycleRuleArray{
+			s3.BucketLifecycleRuleArgs{
+				Id:      pulumi.String("expire-noncurrent-documents"),
+				Enabled: pulumi.Bool(true),
+				NoncurrentVersionExpiration: s3.BucketLifecycleRuleNoncurrentVersionExpirationArgs{
+					Days: pulumi.Int(noncurrentVersionDays),
+				},
+			},
+		},
+		Tags: tags,
+	}, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ownershipControls, err := s3.NewBucketOwnershipControls(ctx, name, &s3.BucketOwnershipControlsArgs{
+		Bucket: bucket.ID(),
+		Rule: s3.BucketOwnershipControlsRuleArgs{
+			ObjectOwnership: pulumi.String("BucketOwnerEnforced"),
+		},
+	}, pulumi.Parent(bucket))
+	if err != nil {
+		return nil, nil, err
+	}
+	return bucket, ownershipControls, nil
+}
+
+// PublishDocuments uploads the issuer documents as JSON objects, the objects
+// carry no ACL, access is granted by the bucket policy
+func PublishDocuments(ctx *pulumi.Context, name string, bucket *s3.Bucket, documents irsacluster.OIDCDocumentsOutput, tags pulumi.StringMap, opts ...pulumi.ResourceOption) error {
+	opts = append([]pulumi.ResourceOption{pulumi.Parent(bucket)}, opts...)
+
+	if _, err := s3.NewBucketObject(ctx, fmt.Sprintf("%s-discovery", name), &s3.BucketObjectArgs{
+		Bucket:      bucket.ID(),
+		Content:     documents.DiscoveryJSON(),
+		ContentType: pulumi.String(documentContentType),
+		Key:         pulumi.String(oidc.OpenIDDiscoveryPath),
+		Tags:        tags,
+	}, opts...); err != nil {
+		return err
+	}
+
+	if _, err := s3.NewBucketObject(ctx, fmt.Sprintf("%s-jwks", name), &s3.BucketObjectArgs{
+		Bucket:      bucket.ID(),
+		Content:     documents.JWKSJSON(),
+		ContentType: pulumi.String(documentContentType),
+		Key:         pulumi.String(oidc.KeysJSON),
+		Tags:        tags,
+	}, opts...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// documentARNs are the arns of the published documents, S3 keys carry no
+// leading slash
+func documentARNs(bucketArn string) []string {
+	var arns []string
+	for _, key := range []string{oidc.OpenIDDiscoveryPath, oidc.KeysJSON} {
+		arns = append(arns, fmt.Sprintf("%s/%s", bucketArn, strings.TrimPrefix(key, "/")))
+	}
+	return arns
+}
@@ -0,0 +1,17 @@
+package bucket
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDocumentARNs(t *testing.T) {
+	expected := []string{
+		"arn:aws:s3:::issuer/.well-known/openid-configuration",
+		"arn:aws:s3:::issuer/keys.json",
+	}
+
+	if actual := documentARNs("arn:aws:s3:::issuer"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %s\n, got: %s\n", expected, actual)
+	}
+}
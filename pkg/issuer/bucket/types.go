@@ -0,0 +1,15 @@
+package bucket
+
+import (
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type bucketConfig struct {
+	pulumiContext *pulumi.Context
+	name          string
+	tags          pulumi.StringMap
+	// bucket is set once the hosting is created
+	bucket       *s3.Bucket
+	bucketPolicy *s3.BucketPolicy
+}
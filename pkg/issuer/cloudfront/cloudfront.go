@@ -0,0 +1,188 @@
+package cloudfront
+
+import (
+	"fmt"
+
+	awsmeta "github.com/frezbo/irsa-anywhere/pkg/aws/meta"
+	irsacluster "github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/component"
+	issuerbucket "github.com/frezbo/irsa-anywhere/pkg/issuer/bucket"
+	awscloudfront "github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudfront"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	originID = "issuer"
+	// cachingDisabledPolicyID is the AWS managed `CachingDisabled` cache policy,
+	// a rotated JWKS has to be served as soon as it is published
+	cachingDisabledPolicyID = "4135ea2d-6df8-44a3-9df3-4b5a84be39ad"
+)
+
+// NewCloudFrontConfig hosts the issuer documents in a private S3 bucket,
+// served through a CloudFront distribution at the distribution domain, or at
+// the domain when one is given. The distribution signs its requests to the
+// bucket through an origin access control
+func NewCloudFrontConfig(ctx *pulumi.Context, name string, domain *Domain) irsacluster.IssuerBucketHost {
+	return &cloudFrontConfig{
+		pulumiContext: ctx,
+		name:          name,
+		domain:        domain,
+	}
+}
+
+func (c *cloudFrontConfig) Create(parent *component.Issuer) (pulumi.StringOutput, error) {
+	if c.domain != nil {
+		if err := c.domain.validate(); err != nil {
+			return pulumi.StringOutput{}, err
+		}
+	}
+
+	tags, err := awsmeta.ResourceTags(c.pulumiContext, c.name)
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	c.tags = tags
+
+	bucket, ownershipControls, err := issuerbucket.NewIssuerBucket(c.pulumiContext, c.name, tags, pulumi.Parent(parent))
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	c.bucket = bucket
+
+	publicAccessBlock, err := s3.NewBucketPublicAccessBlock(c.pulumiContext, c.name, &s3.BucketPublicAccessBlockArgs{
+		Bucket:                bucket.ID(),
+		BlockPublicAcls:       pulumi.Bool(true),
+		BlockPublicPolicy:     pulumi.Bool(true),
+		IgnorePublicAcls:      pulumi.Bool(true),
+		RestrictPublicBuckets: pulumi.Bool(true),
+	}, pulumi.Parent(bucket), pulumi.DependsOn([]pulumi.Resource{ownershipControls}))
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+
+	originAccessControl, err := awscloudfront.NewOriginAccessControl(c.pulumiContext, c.name, &awscloudfront.OriginAccessControlArgs{
+		Description:                   pulumi.Sprintf("%s issuer", c.name),
+		OriginAccessControlOriginType: pulumi.String("s3"),
+		SigningBehavior:               pulumi.String("always"),
+		SigningProtocol:               pulumi.String("sigv4"),
+	}, pulumi.Parent(parent))
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+
+	viewerCertificate := awscloudfront.DistributionViewerCertificateArgs{
+		CloudfrontDefaultCertificate: pulumi.Bool(true),
+	}
+	var aliases pulumi.StringArray
+	var zoneID string
+	if c.domain != nil {
+		var certificateArn pulumi.StringOutput
+		certificateArn, zoneID, err = c.createCertificate(parent)
+		if err != nil {
+			return pulumi.StringOutput{}, err
+		}
+		viewerCertificate = awscloudfront.DistributionViewerCertificateArgs{
+			AcmCertificateArn:      certificateArn,
+			MinimumProtocolVersion: pulumi.String("TLSv1.2_2021"),
+			SslSupportMethod:       pulumi.String("sni-only"),
+		}
+		aliases = pulumi.StringArray{pulumi.String(c.domain.Name)}
+	}
+
+	distribution, err := awscloudfront.NewDistribution(c.pulumiContext, c.name, &awscloudfront.DistributionArgs{
+		Aliases:       aliases,
+		Comment:       pulumi.Sprintf("%s issuer", c.name),
+		Enabled:       pulumi.Bool(true),
+		IsIpv6Enabled: pulumi.Bool(true),
+		Origins: awscloudfront.DistributionOriginArray{
+			awscloudfront.DistributionOriginArgs{
+				DomainName:            bucket.BucketRegionalDomainName,
+				OriginId:              pulumi.String(originID),
+				OriginAccessControlId: originAccessControl.ID(),
+			},
+		},
+		DefaultCacheBehavior: awscloudfront.DistributionDefaultCacheBehaviorArgs{
+			AllowedMethods:       pulumi.ToStringArray([]string{"GET", "HEAD"}),
+			CachedMethods:        pulumi.ToStringArray([]string{"GET", "HEAD"}),
+			CachePolicyId:        pulumi.String(cachingDisabledPolicyID),
+			TargetOriginId:       pulumi.String(originID),
+			ViewerProtocolPolicy: pulumi.String("https-only"),
+		},
+		Restrictions: awscloudfront.DistributionRestrictionsArgs{
+			GeoRestriction: awscloudfront.DistributionRestrictionsGeoRestrictionArgs{
+				RestrictionType: pulumi.String("none"),
+			},
+		},
+		ViewerCertificate: viewerCertificate,
+		Tags:              tags,
+	}, pulumi.Parent(parent))
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+
+	bucketPolicyDocument := pulumi.All(distribution.Arn, bucket.Arn).ApplyT(func(args []interface{}) (string, error) {
+		distributionArn := args[0].(string)
+		bucketArn := args[1].(string)
+
+		bucketPolicy, err := iam.GetPolicyDocument(c.pulumiContext, &iam.GetPolicyDocumentArgs{
+			Statements: bucketPolicyStatements(distributionArn, bucketArn),
+		}, pulumi.Parent(parent))
+		if err != nil {
+			return "", err
+		}
+		return bucketPolicy.Json, nil
+	})
+
+	// a bucket policy cannot be put while the public access block is being applied
+	if _, err := s3.NewBucketPolicy(c.pulumiContext, c.name, &s3.BucketPolicyArgs{
+		Bucket: bucket.ID(),
+		Policy: bucketPolicyDocument,
+	}, pulumi.Parent(bucket), pulumi.DependsOn([]pulumi.Resource{publicAccessBlock})); err != nil {
+		return pulumi.StringOutput{}, err
+	}
+
+	if c.domain != nil {
+		domainName, err := c.createAliasRecords(zoneID, distribution)
+		if err != nil {
+			return pulumi.StringOutput{}, err
+		}
+		return pulumi.Sprintf("https://%s", domainName), nil
+	}
+	return pulumi.Sprintf("https://%s", distribution.DomainName), nil
+}
+
+// bucketPolicyStatements only lets CloudFront read objects on behalf of the
+// distribution, the bucket stays closed to everyone else
+func bucketPolicyStatements(distributionArn, bucketArn string) []iam.GetPolicyDocumentStatement {
+	return []iam.GetPolicyDocumentStatement{
+		{
+			Sid:     &[]string{"allowCloudFrontGetObject"}[0],
+			Actions: []string{"s3:GetObject"},
+			Principals: []iam.GetPolicyDocumentStatementPrincipal{
+				{
+					Type:        "Service",
+					Identifiers: []string{"cloudfront.amazonaws.com"},
+				},
+			},
+			Resources: []string{fmt.Sprintf("%s/*", bucketArn)},
+			Conditions: []iam.GetPolicyDocumentStatementCondition{
+				{
+					Test:     "StringEquals",
+					Variable: "AWS:SourceArn",
+					Values:   []string{distributionArn},
+				},
+			},
+		},
+	}
+}
+
+// Bucket is the name of the bucket the documents are published to
+func (c *cloudFrontConfig) Bucket() pulumi.StringOutput {
+	return c.bucket.Bucket
+}
+
+func (c *cloudFrontConfig) Publish(documents irsacluster.OIDCDocumentsOutput) error {
+	return issuerbucket.PublishDocuments(c.pulumiContext, c.name, c.bucket, documents, c.tags)
+}
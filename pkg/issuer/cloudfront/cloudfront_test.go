@@ -0,0 +1,44 @@
+package cloudfront
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+)
+
+func TestBucketPolicyStatements(t *testing.T) {
+	distributionArn := "arn:aws:cloudfront::123456789012:distribution/E2QWRUHAPOMQZL"
+	statements := bucketPolicyStatements(distributionArn, "arn:aws:s3:::kind-aws")
+	if len(statements) != 1 {
+		t.Fatalf("expected a single statement, got: %d", len(statements))
+	}
+	statement := statements[0]
+
+	if expected := []string{"s3:GetObject"}; !reflect.DeepEqual(statement.Actions, expected) {
+		t.Errorf("expected: %s\n, got: %s\n", expected, statement.Actions)
+	}
+	if expected := []string{"arn:aws:s3:::kind-aws/*"}; !reflect.DeepEqual(statement.Resources, expected) {
+		t.Errorf("expected: %s\n, got: %s\n", expected, statement.Resources)
+	}
+	expectedPrincipals := []iam.GetPolicyDocumentStatementPrincipal{
+		{
+			Type:        "Service",
+			Identifiers: []string{"cloudfront.amazonaws.com"},
+		},
+	}
+	if !reflect.DeepEqual(statement.Principals, expectedPrincipals) {
+		t.Errorf("expected: %+v\n, got: %+v\n", expectedPrincipals, statement.Principals)
+	}
+	// any other distribution is denied
+	expectedConditions := []iam.GetPolicyDocumentStatementCondition{
+		{
+			Test:     "StringEquals",
+			Variable: "AWS:SourceArn",
+			Values:   []string{distributionArn},
+		},
+	}
+	if !reflect.DeepEqual(statement.Conditions, expectedConditions) {
+		t.Errorf("expected: %+v\n, got: %+v\n", expectedConditions, statement.Conditions)
+	}
+}
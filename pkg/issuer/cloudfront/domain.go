@@ -0,0 +1,124 @@
+package cloudfront
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/acm"
+	awscloudfront "github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudfront"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/route53"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// certificateRegion is the only region CloudFront takes ACM certificates from
+const certificateRegion = "us-east-1"
+
+// Domain is a domain we own serving the issuer, keeping the issuer url stable
+// when the bucket or distribution is replaced
+type Domain struct {
+	// Name is the issuer host name, e.g. oidc.dev.example.com
+	Name string
+	// Zone is the public Route53 hosted zone the records are created in,
+	// defaults to the parent domain of Name
+	Zone string
+}
+
+func (d *Domain) validate() error {
+	if d.Name == "" || strings.ContainsAny(d.Name, "/:") || strings.HasSuffix(d.Name, ".") {
+		return errors.Errorf("issuer domain must be a bare host name, got %q", d.Name)
+	}
+	if zone := d.zone(); zone == "" || (d.Name != zone && !strings.HasSuffix(d.Name, "."+zone)) {
+		return errors.Errorf("issuer domain %s is not part of the zone %q", d.Name, zone)
+	}
+	return nil
+}
+
+func (d *Domain) zone() string {
+	if d.Zone != "" {
+		return strings.TrimSuffix(d.Zone, ".")
+	}
+	if i := strings.Index(d.Name, "."); i >= 0 {
+		return d.Name[i+1:]
+	}
+	return ""
+}
+
+// createCertificate issues a DNS validated ACM certificate for the domain,
+// returning the arn once it is validated along with the hosted zone id
+func (c *cloudFrontConfig) createCertificate(parent pulumi.Resource) (pulumi.StringOutput, string, error) {
+	zone, err := route53.LookupZone(c.pulumiContext, &route53.LookupZoneArgs{
+		Name:        &[]string{c.domain.zone()}[0],
+		PrivateZone: &[]bool{false}[0],
+	}, pulumi.Parent(parent))
+	if err != nil {
+		return pulumi.StringOutput{}, "", errors.Wrapf(err, "failed to find the hosted zone %s", c.domain.zone())
+	}
+
+	certificateProvider, err := aws.NewProvider(c.pulumiContext, fmt.Sprintf("%s-%s", c.name, certificateRegion), &aws.ProviderArgs{
+		Region: pulumi.String(certificateRegion),
+	}, pulumi.Parent(parent))
+	if err != nil {
+		return pulumi.StringOutput{}, "", err
+	}
+
+	certificate, err := acm.NewCertificate(c.pulumiContext, c.name, &acm.CertificateArgs{
+		DomainName:       pulumi.String(c.domain.Name),
+		ValidationMethod: pulumi.String("DNS"),
+		Tags:             c.tags,
+	}, pulumi.Parent(parent), pulumi.Provider(certificateProvider))
+	if err != nil {
+		return pulumi.StringOutput{}, "", err
+	}
+
+	validationOption := certificate.DomainValidationOptions.Index(pulumi.Int(0))
+	validationRecord, err := route53.NewRecord(c.pulumiContext, fmt.Sprintf("%s-validation", c.name), &route53.RecordArgs{
+		ZoneId:         pulumi.String(zone.ZoneId),
+		Name:           validationOption.ResourceRecordName().Elem(),
+		Type:           validationOption.ResourceRecordType().Elem(),
+		Records:        pulumi.StringArray{validationOption.ResourceRecordValue().Elem()},
+		Ttl:            pulumi.Int(60),
+		AllowOverwrite: pulumi.Bool(true),
+	}, pulumi.Parent(certificate))
+	if err != nil {
+		return pulumi.StringOutput{}, "", err
+	}
+
+	validation, err := acm.NewCertificateValidation(c.pulumiContext, c.name, &acm.CertificateValidationArgs{
+		CertificateArn:        certificate.Arn,
+		ValidationRecordFqdns: pulumi.StringArray{validationRecord.Fqdn},
+	}, pulumi.Parent(certificate), pulumi.Provider(certificateProvider))
+	if err != nil {
+		return pulumi.StringOutput{}, "", err
+	}
+	return validation.CertificateArn, zone.ZoneId, nil
+}
+
+// createAliasRecords points the domain at the distribution, the returned
+// domain name resolves once the records exist so the thumbprint lookup on the
+// issuer url waits for them
+func (c *cloudFrontConfig) createAliasRecords(zoneID string, distribution *awscloudfront.Distribution) (pulumi.StringOutput, error) {
+	var fqdns []interface{}
+	for _, recordType := range []string{"A", "AAAA"} {
+		record, err := route53.NewRecord(c.pulumiContext, fmt.Sprintf("%s-%s", c.name, strings.ToLower(recordType)), &route53.RecordArgs{
+			ZoneId: pulumi.String(zoneID),
+			Name:   pulumi.String(c.domain.Name),
+			Type:   pulumi.String(recordType),
+			Aliases: route53.RecordAliasArray{
+				route53.RecordAliasArgs{
+					Name:                 distribution.DomainName,
+					ZoneId:               distribution.HostedZoneId,
+					EvaluateTargetHealth: pulumi.Bool(false),
+				},
+			},
+		}, pulumi.Parent(distribution))
+		if err != nil {
+			return pulumi.StringOutput{}, err
+		}
+		fqdns = append(fqdns, record.Fqdn)
+	}
+	return pulumi.All(fqdns...).ApplyT(func([]interface{}) string {
+		return c.domain.Name
+	}).(pulumi.StringOutput), nil
+}
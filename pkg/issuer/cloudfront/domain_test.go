@@ -0,0 +1,41 @@
+package cloudfront
+
+import "testing"
+
+func TestDomainZone(t *testing.T) {
+	for _, tc := range []struct {
+		domain   Domain
+		expected string
+	}{
+		{Domain{Name: "oidc.dev.example.com"}, "dev.example.com"},
+		{Domain{Name: "oidc.dev.example.com", Zone: "example.com."}, "example.com"},
+	} {
+		if actual := tc.domain.zone(); actual != tc.expected {
+			t.Errorf("expected: %s\n, got: %s\n", tc.expected, actual)
+		}
+	}
+}
+
+func TestDomainValidate(t *testing.T) {
+	for _, domain := range []Domain{
+		{Name: "oidc.dev.example.com"},
+		{Name: "oidc.dev.example.com", Zone: "example.com"},
+		{Name: "example.com", Zone: "example.com"},
+	} {
+		if err := domain.validate(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	for _, domain := range []Domain{
+		{Name: "https://oidc.example.com"},
+		{Name: "oidc.example.com."},
+		{Name: "localhost"},
+		{Name: "oidc.dev.example.com", Zone: "other.com"},
+		{Name: "oidc.badexample.com", Zone: "example.com"},
+	} {
+		if err := domain.validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", domain)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package cloudfront
+
+import (
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type cloudFrontConfig struct {
+	pulumiContext *pulumi.Context
+	name          string
+	domain        *Domain
+	tags          pulumi.StringMap
+	// bucket is set once the hosting is created
+	bucket *s3.Bucket
+}
@@ -0,0 +1,101 @@
+package directory
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	irsacluster "github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/component"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi-command/sdk/go/command/local"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	discoveryEnv = "IRSA_DISCOVERY_JSON"
+	jwksEnv      = "IRSA_JWKS_JSON"
+)
+
+// NewDirectoryConfig writes the issuer documents to a local directory tree
+// laid out the way they are served, the directory has to be served at
+// issuerURL by a static host for AWS to reach them
+func NewDirectoryConfig(ctx *pulumi.Context, name, dir, issuerURL string) irsacluster.IssuerHost {
+	return &directoryConfig{
+		pulumiContext: ctx,
+		name:          name,
+		dir:           dir,
+		issuerURL:     issuerURL,
+	}
+}
+
+func (c *directoryConfig) Create(parent *component.Issuer) (pulumi.StringOutput, error) {
+	if err := validateIssuerURL(c.issuerURL); err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	dir, err := filepath.Abs(c.dir)
+	if err != nil {
+		return pulumi.StringOutput{}, errors.Wrapf(err, "failed to resolve issuer directory: %s", c.dir)
+	}
+	// the directory is single quoted in the commands writing the documents
+	if strings.Contains(dir, "'") {
+		return pulumi.StringOutput{}, errors.Errorf("issuer directory %s must not contain a single quote", dir)
+	}
+	c.dir = dir
+	c.parent = parent
+	return pulumi.String(c.issuerURL).ToStringOutput(), nil
+}
+
+func (c *directoryConfig) Publish(documents irsacluster.OIDCDocumentsOutput) error {
+	discoveryJSON := documents.DiscoveryJSON()
+	jwksJSON := documents.JWKSJSON()
+
+	// the documents are passed through the environment so that they are
+	// never interpreted by the shell
+	_, err := local.NewCommand(c.pulumiContext, c.name, &local.CommandArgs{
+		Create: pulumi.String(toWriteCommand(c.dir)),
+		Delete: pulumi.String(toDeleteCommand(c.dir)),
+		Environment: pulumi.StringMap{
+			discoveryEnv: discoveryJSON,
+			jwksEnv:      jwksJSON,
+		},
+		Triggers: pulumi.Array{discoveryJSON, jwksJSON},
+	}, pulumi.Parent(c.parent))
+	return err
+}
+
+// validateIssuerURL makes sure the url is usable as an issuer by both the
+// apiserver and AWS
+func validateIssuerURL(issuerURL string) error {
+	u, err := url.Parse(issuerURL)
+	if err != nil {
+		return errors.Wrapf(err, "invalid issuer url: %s", issuerURL)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return errors.Errorf("issuer url %s must be an https url", issuerURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" || strings.HasSuffix(u.Path, "/") {
+		return errors.Errorf("issuer url %s must not have a query, fragment or trailing slash", issuerURL)
+	}
+	return nil
+}
+
+func documentPaths(dir string) (string, string) {
+	return filepath.Join(dir, filepath.FromSlash(oidc.OpenIDDiscoveryPath)), filepath.Join(dir, oidc.KeysJSON)
+}
+
+func toWriteCommand(dir string) string {
+	discoveryPath, jwksPath := documentPaths(dir)
+	return strings.Join([]string{
+		fmt.Sprintf("mkdir -p '%s'", filepath.Dir(discoveryPath)),
+		fmt.Sprintf("printf '%%s' \"$%s\" > '%s'", discoveryEnv, discoveryPath),
+		fmt.Sprintf("printf '%%s' \"$%s\" > '%s'", jwksEnv, jwksPath),
+	}, " && ")
+}
+
+func toDeleteCommand(dir string) string {
+	discoveryPath, jwksPath := documentPaths(dir)
+	return fmt.Sprintf("rm -f '%s' '%s'", discoveryPath, jwksPath)
+}
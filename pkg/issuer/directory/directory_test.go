@@ -0,0 +1,54 @@
+package directory
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateIssuerURL(t *testing.T) {
+	for _, issuerURL := range []string{"https://issuer.example.com", "https://example.com/issuer"} {
+		if err := validateIssuerURL(issuerURL); err != nil {
+			t.Error(err)
+		}
+	}
+
+	for _, issuerURL := range []string{"http://issuer.example.com", "issuer.example.com", "https://example.com/issuer/", "https://example.com?issuer"} {
+		if err := validateIssuerURL(issuerURL); err == nil {
+			t.Errorf("expected %s to be rejected", issuerURL)
+		}
+	}
+}
+
+func TestWriteCommand(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "issuer")
+	discoveryJSON := `{"issuer":"https://issuer.example.com"}`
+	jwksJSON := `{"keys":[{"kid":"$HOME \"quoted\" %s"}]}`
+
+	cmd := exec.Command("sh", "-c", toWriteCommand(dir))
+	cmd.Env = append(os.Environ(), discoveryEnv+"="+discoveryJSON, jwksEnv+"="+jwksJSON)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+
+	for path, expected := range map[string]string{
+		filepath.Join(dir, ".well-known", "openid-configuration"): discoveryJSON,
+		filepath.Join(dir, "keys.json"):                           jwksJSON,
+	} {
+		actual, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(actual) != expected {
+			t.Errorf("expected: %s\n, got: %s\n", expected, actual)
+		}
+	}
+
+	if out, err := exec.Command("sh", "-c", toDeleteCommand(dir)).CombinedOutput(); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "keys.json")); !os.IsNotExist(err) {
+		t.Errorf("expected the JWKS to be deleted, got: %v", err)
+	}
+}
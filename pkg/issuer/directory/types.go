@@ -0,0 +1,15 @@
+package directory
+
+import (
+	"github.com/frezbo/irsa-anywhere/pkg/component"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type directoryConfig struct {
+	pulumiContext *pulumi.Context
+	name          string
+	dir           string
+	issuerURL     string
+	// parent is set once the hosting is created
+	parent *component.Issuer
+}
@@ -4,5 +4,4 @@ const (
 	JWKSDiscoveryPath   = "/openid/v1/jwks"
 	OpenIDDiscoveryPath = "/.well-known/openid-configuration"
 	KeysJSON            = "keys.json"
-	DiscoveryJSON       = "discovery.json"
 )
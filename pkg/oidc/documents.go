@@ -0,0 +1,153 @@
+package oidc
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ParseDocuments parses the discovery document and JWKS
+func ParseDocuments(discoveryJSON, jwksJSON []byte) (*Documents, error) {
+	discovery, err := ParseDiscovery(discoveryJSON)
+	if err != nil {
+		return nil, err
+	}
+	keySet, err := ParseJWKS(jwksJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &Documents{
+		Discovery: *discovery,
+		JWKS:      *keySet,
+	}, nil
+}
+
+// ParseDiscovery parses an openid discovery document
+func ParseDiscovery(data []byte) (*Discovery, error) {
+	var discovery Discovery
+	if err := json.Unmarshal(data, &discovery); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the openid discovery document")
+	}
+	return &discovery, nil
+}
+
+// ParseJWKS parses a JWKS
+func ParseJWKS(data []byte) (*JSONWebKeySet, error) {
+	var keySet JSONWebKeySet
+	if err := json.Unmarshal(data, &keySet); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the JWKS")
+	}
+	return &keySet, nil
+}
+
+// Marshal returns the document the same way the apiserver serves it
+func (d Discovery) Marshal() (string, error) {
+	data, err := json.Marshal(&d)
+	return string(data), errors.Wrap(err, "failed to marshal the openid discovery document")
+}
+
+// Marshal returns the JWKS the same way the apiserver serves it
+func (k JSONWebKeySet) Marshal() (string, error) {
+	data, err := json.Marshal(&k)
+	return string(data), errors.Wrap(err, "failed to marshal the JWKS")
+}
+
+// Equal ignores the difference between empty and missing lists
+func (d Discovery) Equal(other Discovery) bool {
+	return d.Issuer == other.Issuer &&
+		d.JWKSURI == other.JWKSURI &&
+		equalStrings(d.ResponseTypesSupported, other.ResponseTypesSupported) &&
+		equalStrings(d.SubjectTypesSupported, other.SubjectTypesSupported) &&
+		equalStrings(d.IDTokenSigningAlgValuesSupported, other.IDTokenSigningAlgValuesSupported)
+}
+
+// Equal compares the keys of both sets regardless of their order
+func (k JSONWebKeySet) Equal(other JSONWebKeySet) bool {
+	return len(k.Keys) == len(other.Keys) && k.Diff(other).Empty()
+}
+
+// Equal compares both documents
+func (d Documents) Equal(other Documents) bool {
+	return d.Discovery.Equal(other.Discovery) && d.JWKS.Equal(other.JWKS)
+}
+
+// Key looks up a key by its id
+func (k JSONWebKeySet) Key(kid string) (JSONWebKey, bool) {
+	for _, key := range k.Keys {
+		if key.Kid == kid {
+			return key, true
+		}
+	}
+	return JSONWebKey{}, false
+}
+
+// SigningAlgs returns the sorted signing algorithms of the keys, as listed in
+// the discovery document
+func (k JSONWebKeySet) SigningAlgs() []string {
+	seen := map[string]bool{}
+	var algs []string
+	for _, key := range k.Keys {
+		if !seen[key.Alg] {
+			seen[key.Alg] = true
+			algs = append(algs, key.Alg)
+		}
+	}
+	sort.Strings(algs)
+	return algs
+}
+
+// Diff lists the keys added, removed or changed in other compared to k
+func (k JSONWebKeySet) Diff(other JSONWebKeySet) JWKSDiff {
+	var diff JWKSDiff
+	for _, key := range other.Keys {
+		existing, ok := k.Key(key.Kid)
+		if !ok {
+			diff.Added = append(diff.Added, key.Kid)
+		} else if existing != key {
+			diff.Changed = append(diff.Changed, key.Kid)
+		}
+	}
+	for _, key := range k.Keys {
+		if _, ok := other.Key(key.Kid); !ok {
+			diff.Removed = append(diff.Removed, key.Kid)
+		}
+	}
+	return diff
+}
+
+// Empty is true when both key sets hold the same keys
+func (d JWKSDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// MergeJWKS combines the keys of every set in order, keys present in more
+// than one set are kept once and must be identical
+func MergeJWKS(keySets ...JSONWebKeySet) (JSONWebKeySet, error) {
+	var merged JSONWebKeySet
+	for _, keySet := range keySets {
+		for _, key := range keySet.Keys {
+			existing, ok := merged.Key(key.Kid)
+			if !ok {
+				merged.Keys = append(merged.Keys, key)
+				continue
+			}
+			if existing != key {
+				return merged, errors.Errorf("key %s is present with different values", key.Kid)
+			}
+		}
+	}
+	return merged, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
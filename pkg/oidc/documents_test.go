@@ -0,0 +1,87 @@
+package oidc
+
+import (
+	"reflect"
+	"testing"
+)
+
+var (
+	testKeyA = JSONWebKey{Use: "sig", Kty: "RSA", Kid: "a", Alg: "RS256", N: "xyz", E: "AQAB"}
+	testKeyB = JSONWebKey{Use: "sig", Kty: "EC", Kid: "b", Crv: "P-256", Alg: "ES256", X: "x", Y: "y"}
+)
+
+func TestDocumentsRoundTrip(t *testing.T) {
+	documents, err := ParseDocuments([]byte(testDiscoveryJSON), []byte(testJWKSJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discoveryJSON, err := documents.Discovery.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if discoveryJSON != testDiscoveryJSON {
+		t.Errorf("expected: %s\n, got: %s\n", testDiscoveryJSON, discoveryJSON)
+	}
+
+	jwksJSON, err := documents.JWKS.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwksJSON != testJWKSJSON {
+		t.Errorf("expected: %s\n, got: %s\n", testJWKSJSON, jwksJSON)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !(JSONWebKeySet{Keys: []JSONWebKey{testKeyA, testKeyB}}).Equal(JSONWebKeySet{Keys: []JSONWebKey{testKeyB, testKeyA}}) {
+		t.Error("expected key sets with the same keys in a different order to be equal")
+	}
+	if (JSONWebKeySet{Keys: []JSONWebKey{testKeyA, testKeyB}}).Equal(JSONWebKeySet{Keys: []JSONWebKey{testKeyA}}) {
+		t.Error("expected key sets with different keys to not be equal")
+	}
+
+	discovery := NewDiscovery(testIssuerURL, []string{"RS256"})
+	if !discovery.Equal(NewDiscovery(testIssuerURL, []string{"RS256"})) {
+		t.Error("expected the same discovery documents to be equal")
+	}
+	if discovery.Equal(NewDiscovery(testIssuerURL, []string{"ES256", "RS256"})) {
+		t.Error("expected discovery documents with different signing algorithms to not be equal")
+	}
+}
+
+func TestMergeJWKS(t *testing.T) {
+	merged, err := MergeJWKS(JSONWebKeySet{Keys: []JSONWebKey{testKeyA}}, JSONWebKeySet{Keys: []JSONWebKey{testKeyA, testKeyB}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := JSONWebKeySet{Keys: []JSONWebKey{testKeyA, testKeyB}}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected: %+v\n, got: %+v\n", expected, merged)
+	}
+
+	conflicting := testKeyA
+	conflicting.N = "abc"
+	if _, err := MergeJWKS(JSONWebKeySet{Keys: []JSONWebKey{testKeyA}}, JSONWebKeySet{Keys: []JSONWebKey{conflicting}}); err == nil {
+		t.Error("expected an error when merging different keys with the same kid")
+	}
+}
+
+func TestJWKSDiff(t *testing.T) {
+	changed := testKeyA
+	changed.N = "abc"
+	diff := JSONWebKeySet{Keys: []JSONWebKey{testKeyA}}.Diff(JSONWebKeySet{Keys: []JSONWebKey{changed, testKeyB}})
+	expected := JWKSDiff{Added: []string{"b"}, Changed: []string{"a"}}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected: %+v\n, got: %+v\n", expected, diff)
+	}
+
+	diff = JSONWebKeySet{Keys: []JSONWebKey{testKeyA, testKeyB}}.Diff(JSONWebKeySet{Keys: []JSONWebKey{testKeyB}})
+	expected = JWKSDiff{Removed: []string{"a"}}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected: %+v\n, got: %+v\n", expected, diff)
+	}
+	if diff.Empty() {
+		t.Error("expected a removed key to not be an empty diff")
+	}
+}
@@ -0,0 +1,48 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Drift is how the published issuer documents differ from the ones the
+// cluster serves
+type Drift struct {
+	// Discovery is set when the published discovery document differs
+	Discovery bool
+	// JWKS lists the keys served by the cluster but not published as added,
+	// and the published keys the cluster no longer serves as removed
+	JWKS JWKSDiff
+}
+
+// CompareDocuments reports the drift of the published documents from the
+// documents served by the cluster
+func CompareDocuments(published, served Documents) Drift {
+	return Drift{
+		Discovery: !published.Discovery.Equal(served.Discovery),
+		JWKS:      published.JWKS.Diff(served.JWKS),
+	}
+}
+
+// Empty is true when the published documents match the served ones
+func (d Drift) Empty() bool {
+	return !d.Discovery && d.JWKS.Empty()
+}
+
+// String describes the drift one line per key
+func (d Drift) String() string {
+	var lines []string
+	if d.Discovery {
+		lines = append(lines, "discovery document differs")
+	}
+	for _, kid := range d.JWKS.Added {
+		lines = append(lines, fmt.Sprintf("key %s is served by the cluster but not published", kid))
+	}
+	for _, kid := range d.JWKS.Removed {
+		lines = append(lines, fmt.Sprintf("key %s is published but no longer served by the cluster", kid))
+	}
+	for _, kid := range d.JWKS.Changed {
+		lines = append(lines, fmt.Sprintf("key %s is published with different values than served by the cluster", kid))
+	}
+	return strings.Join(lines, "\n")
+}
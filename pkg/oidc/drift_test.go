@@ -0,0 +1,43 @@
+package oidc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareDocuments(t *testing.T) {
+	rotated := testKeyA
+	rotated.N = "abc"
+	published := Documents{
+		Discovery: NewDiscovery(testIssuerURL, []string{"RS256"}),
+		JWKS:      JSONWebKeySet{Keys: []JSONWebKey{testKeyA}},
+	}
+
+	if drift := CompareDocuments(published, published); !drift.Empty() {
+		t.Errorf("expected no drift, got: %s\n", drift)
+	}
+
+	served := Documents{
+		Discovery: NewDiscovery(testIssuerURL, []string{"ES256", "RS256"}),
+		JWKS:      JSONWebKeySet{Keys: []JSONWebKey{testKeyB}},
+	}
+	drift := CompareDocuments(published, served)
+	expected := Drift{Discovery: true, JWKS: JWKSDiff{Added: []string{"b"}, Removed: []string{"a"}}}
+	if !reflect.DeepEqual(drift, expected) {
+		t.Errorf("expected: %+v\n, got: %+v\n", expected, drift)
+	}
+	expectedReport := "discovery document differs\nkey b is served by the cluster but not published\nkey a is published but no longer served by the cluster"
+	if drift.String() != expectedReport {
+		t.Errorf("expected: %s\n, got: %s\n", expectedReport, drift)
+	}
+
+	served = Documents{
+		Discovery: published.Discovery,
+		JWKS:      JSONWebKeySet{Keys: []JSONWebKey{rotated}},
+	}
+	drift = CompareDocuments(published, served)
+	expected = Drift{JWKS: JWKSDiff{Changed: []string{"a"}}}
+	if !reflect.DeepEqual(drift, expected) {
+		t.Errorf("expected: %+v\n, got: %+v\n", expected, drift)
+	}
+}
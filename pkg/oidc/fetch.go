@@ -0,0 +1,47 @@
+package oidc
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FetchDocuments downloads the published discovery document of the issuer and
+// the JWKS it points to
+func FetchDocuments(client *http.Client, issuerURL string) (*Documents, error) {
+	discoveryJSON, err := fetch(client, strings.TrimSuffix(issuerURL, "/")+OpenIDDiscoveryPath)
+	if err != nil {
+		return nil, err
+	}
+	discovery, err := ParseDiscovery(discoveryJSON)
+	if err != nil {
+		return nil, err
+	}
+	jwksJSON, err := fetch(client, discovery.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	keySet, err := ParseJWKS(jwksJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &Documents{
+		Discovery: *discovery,
+		JWKS:      *keySet,
+	}, nil
+}
+
+func fetch(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to get %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return data, errors.Wrapf(err, "failed to read %s", url)
+}
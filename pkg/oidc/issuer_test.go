@@ -0,0 +1,75 @@
+package oidc_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc/oidctest"
+)
+
+func TestValidateIssuer(t *testing.T) {
+	issuer, err := oidctest.NewIssuer(oidctest.AlgorithmRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+
+	if err := validateServed(issuer); err != nil {
+		t.Error(err)
+	}
+
+	// both keys are published while rotating
+	if _, err := issuer.AddKey(oidctest.AlgorithmES256); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateServed(issuer); err != nil {
+		t.Error(err)
+	}
+}
+
+func validateServed(issuer *oidctest.Issuer) error {
+	discoveryJSON, err := get(issuer, oidc.OpenIDDiscoveryPath)
+	if err != nil {
+		return err
+	}
+	jwksJSON, err := get(issuer, "/"+oidc.KeysJSON)
+	if err != nil {
+		return err
+	}
+	documents, err := oidc.ParseDocuments([]byte(discoveryJSON), []byte(jwksJSON))
+	if err != nil {
+		return err
+	}
+	return documents.Validate(issuer.URL)
+}
+
+func get(issuer *oidctest.Issuer, path string) (string, error) {
+	resp, err := issuer.Client().Get(issuer.URL + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+func TestFetchCertificateChain(t *testing.T) {
+	issuer, err := oidctest.NewIssuer(oidctest.AlgorithmRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+
+	chainPEM, err := oidc.FetchCertificateChain(issuer.Client(), issuer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbprint, err := oidc.IssuerThumbprint(chainPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := oidc.Thumbprint(issuer.Certificate()); thumbprint != expected {
+		t.Errorf("expected: %s\n, got: %s\n", expected, thumbprint)
+	}
+}
@@ -0,0 +1,192 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// DocumentsFromPublicKeys builds the discovery document and JWKS for the given
+// public keys without a running cluster
+func DocumentsFromPublicKeys(issuerURL, publicKeysPEM string) (*Documents, error) {
+	keySet, err := JWKSFromPublicKeys(publicKeysPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &Documents{
+		Discovery: NewDiscovery(issuerURL, keySet.SigningAlgs()),
+		JWKS:      *keySet,
+	}, nil
+}
+
+// NewDiscovery returns the discovery document the apiserver serves for the
+// issuer when `--service-account-jwks-uri` points next to it
+func NewDiscovery(issuerURL string, signingAlgs []string) Discovery {
+	return Discovery{
+		Issuer:                           issuerURL,
+		JWKSURI:                          fmt.Sprintf("%s/%s", issuerURL, KeysJSON),
+		ResponseTypesSupported:           []string{"id_token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: signingAlgs,
+	}
+}
+
+// JWKSFromPublicKeys builds the JWKS for the given PEM encoded public keys, in
+// the order they appear, the same way the apiserver does for
+// `--service-account-key-file`
+func JWKSFromPublicKeys(publicKeysPEM string) (*JSONWebKeySet, error) {
+	keys, err := ParsePublicKeysPEM(publicKeysPEM)
+	if err != nil {
+		return nil, err
+	}
+	keySet := &JSONWebKeySet{}
+	for _, key := range keys {
+		jwk, err := jwkFromPublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		keySet.Keys = append(keySet.Keys, *jwk)
+	}
+	return keySet, nil
+}
+
+// ParsePublicKeysPEM parses every RSA or ECDSA public key in the PEM data
+func ParsePublicKeysPEM(publicKeysPEM string) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	rest := []byte(publicKeysPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		var key crypto.PublicKey
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse public key %d", len(keys))
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no public keys found in PEM data")
+	}
+	return keys, nil
+}
+
+// KeyID computes the `kid` of a public key the same way the apiserver does, as
+// the unpadded base64url encoded SHA-256 hash of its PKIX DER encoding
+func KeyID(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to serialize public key")
+	}
+	hash := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
+
+func jwkFromPublicKey(publicKey crypto.PublicKey) (*JSONWebKey, error) {
+	kid, err := KeyID(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return &JSONWebKey{
+			Use: "sig",
+			Kty: "RSA",
+			Kid: kid,
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		var alg string
+		switch key.Curve {
+		case elliptic.P256():
+			alg = "ES256"
+		case elliptic.P384():
+			alg = "ES384"
+		case elliptic.P521():
+			alg = "ES512"
+		default:
+			return nil, errors.Errorf("unsupported ECDSA curve: %s", key.Curve.Params().Name)
+		}
+		// coordinates are padded to the curve size as required by RFC 7518
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return &JSONWebKey{
+			Use: "sig",
+			Kty: "EC",
+			Kid: kid,
+			Crv: key.Curve.Params().Name,
+			Alg: alg,
+			X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported public key type: %T", publicKey)
+	}
+}
+
+// PublicKey decodes the public key of the JWK
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %q of key %s", "n", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %q of key %s", "e", k.Kid)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("field %q of key %s: unsupported curve %q", "crv", k.Kid, k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %q of key %s", "x", k.Kid)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %q of key %s", "y", k.Kid)
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, errors.Errorf("field %q of key %s: unsupported key type %q", "kty", k.Kid, k.Kty)
+	}
+}
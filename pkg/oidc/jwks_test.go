@@ -0,0 +1,81 @@
+package oidc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	// kids computed with
+	// openssl pkey -pubin -outform DER | openssl dgst -sha256 -binary | base64 | tr '+/' '-_' | tr -d '='
+	testRSAKeyID   = "zGKUyivBl1V4QQ6dqgVsDXJADvwxtkaNBRkSCgtmitM"
+	testECDSAKeyID = "CAM1MXXUOE2XqMaf9ctOTG9kC1VZXA7-JFBKKeIRAfU"
+)
+
+func readTestKey(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestKeyID(t *testing.T) {
+	tests := map[string]string{
+		"rsa.pub":   testRSAKeyID,
+		"ecdsa.pub": testECDSAKeyID,
+	}
+	for file, expected := range tests {
+		keys, err := ParsePublicKeysPEM(readTestKey(t, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kid, err := KeyID(keys[0]); err != nil {
+			t.Error(err)
+		} else if kid != expected {
+			t.Errorf("expected: %s\n, got: %s\n", expected, kid)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	expected := `{"keys":[{"use":"sig","kty":"EC","kid":"CAM1MXXUOE2XqMaf9ctOTG9kC1VZXA7-JFBKKeIRAfU","crv":"P-256","alg":"ES256","x":"TAXDyU0upDR2MOou_KtN4e86B8iLLkjAf4yOlxLgBZs","y":"q4TWV33X5CpMBRYoUupvgMTt4iymtz3vCnn4ezOj08g"}]}`
+	keySet, err := JWKSFromPublicKeys(readTestKey(t, "ecdsa.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksJSON, err := keySet.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwksJSON != expected {
+		t.Errorf("expected: %s\n, got: %s\n", expected, jwksJSON)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	documents, err := DocumentsFromPublicKeys(testIssuerURL, readTestKey(t, "rsa.pub")+readTestKey(t, "ecdsa.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	discoveryJSON, err := documents.Discovery.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"issuer":"https://somedomain","jwks_uri":"https://somedomain/keys.json","response_types_supported":["id_token"],"subject_types_supported":["public"],"id_token_signing_alg_values_supported":["ES256","RS256"]}`
+	if discoveryJSON != expected {
+		t.Errorf("expected: %s\n, got: %s\n", expected, discoveryJSON)
+	}
+	if err := documents.Validate(testIssuerURL); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParsePublicKeysPEMErrors(t *testing.T) {
+	if _, err := ParsePublicKeysPEM(""); err == nil {
+		t.Error("expected an error when no public keys are found")
+	}
+}
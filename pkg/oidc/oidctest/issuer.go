@@ -0,0 +1,277 @@
+// Package oidctest provides a service account issuer served over HTTPS and a
+// token minter for tests, so the OIDC flow can be tested without AWS or a
+// cluster
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pkg/errors"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+
+	// DefaultTokenLifetime matches the default expiration of projected
+	// service account tokens
+	DefaultTokenLifetime = time.Hour
+)
+
+// Issuer serves the discovery document and JWKS of the keys it signs tokens
+// with, the JWKS is served both at `oidc.KeysJSON` like the issuer bucket and
+// at `oidc.JWKSDiscoveryPath` like the apiserver
+type Issuer struct {
+	// URL is the issuer url, `https://127.0.0.1:<port>`
+	URL string
+
+	server     *httptest.Server
+	mu         sync.RWMutex
+	keys       []*signingKey
+	signingKid string
+}
+
+type signingKey struct {
+	kid          string
+	alg          string
+	signer       crypto.Signer
+	publicKeyPEM string
+}
+
+// NewIssuer starts an issuer signing tokens with a new key of the given
+// algorithm, it must be closed once done
+func NewIssuer(algorithm string) (*Issuer, error) {
+	issuer := &Issuer{}
+	kid, err := issuer.AddKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	issuer.signingKid = kid
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidc.OpenIDDiscoveryPath, issuer.serveDiscovery)
+	mux.HandleFunc("/"+oidc.KeysJSON, issuer.serveJWKS)
+	mux.HandleFunc(oidc.JWKSDiscoveryPath, issuer.serveJWKS)
+	issuer.server = httptest.NewTLSServer(mux)
+	issuer.URL = issuer.server.URL
+	return issuer, nil
+}
+
+// Close shuts down the server
+func (i *Issuer) Close() {
+	i.server.Close()
+}
+
+// Client returns an http client trusting the issuer's certificate
+func (i *Issuer) Client() *http.Client {
+	return i.server.Client()
+}
+
+// Host is the issuer url without the scheme, as used by AWS IAM condition keys
+func (i *Issuer) Host() string {
+	return strings.TrimPrefix(i.URL, "https://")
+}
+
+// Certificate returns the certificate served by the issuer
+func (i *Issuer) Certificate() *x509.Certificate {
+	return i.server.Certificate()
+}
+
+// AddKey adds a verification key to the JWKS, tokens keep being signed with
+// the current signing key until `SignWith` is called
+func (i *Issuer) AddKey(algorithm string) (string, error) {
+	var signer crypto.Signer
+	var err error
+	switch algorithm {
+	case AlgorithmRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return "", errors.Errorf("unsupported signing algorithm: %s, must be one of %s or %s", algorithm, AlgorithmRS256, AlgorithmES256)
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate signing key")
+	}
+
+	kid, err := oidc.KeyID(signer.Public())
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to serialize public key")
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keys = append(i.keys, &signingKey{
+		kid:          kid,
+		alg:          algorithm,
+		signer:       signer,
+		publicKeyPEM: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	})
+	return kid, nil
+}
+
+// SignWith switches the key tokens are signed with
+func (i *Issuer) SignWith(kid string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.key(kid) == nil {
+		return errors.Errorf("key %s not found", kid)
+	}
+	i.signingKid = kid
+	return nil
+}
+
+// RemoveKey drops a key from the JWKS, the signing key cannot be removed
+func (i *Issuer) RemoveKey(kid string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if kid == i.signingKid {
+		return errors.Errorf("key %s is used for signing", kid)
+	}
+	for index, key := range i.keys {
+		if key.kid == kid {
+			i.keys = append(i.keys[:index], i.keys[index+1:]...)
+			return nil
+		}
+	}
+	return errors.Errorf("key %s not found", kid)
+}
+
+// PublicKeysPEM returns every verification key, the same way they are
+// passed to the apiserver with `--service-account-key-file`
+func (i *Issuer) PublicKeysPEM() string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	var pems strings.Builder
+	for _, key := range i.keys {
+		pems.WriteString(key.publicKeyPEM)
+	}
+	return pems.String()
+}
+
+// Documents returns the documents served by the issuer
+func (i *Issuer) Documents() (*oidc.Documents, error) {
+	return oidc.DocumentsFromPublicKeys(i.URL, i.PublicKeysPEM())
+}
+
+// Claims returns the claims of a token for the service account valid from now
+// on for `DefaultTokenLifetime`
+func (i *Issuer) Claims(namespace, serviceAccount string, audiences ...string) oidc.Claims {
+	now := time.Now()
+	return oidc.Claims{
+		Issuer:    i.URL,
+		Subject:   oidc.ServiceAccountSubject(namespace, serviceAccount),
+		Audience:  audiences,
+		Expiry:    now.Add(DefaultTokenLifetime).Unix(),
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		Kubernetes: oidc.KubernetesClaims{
+			Namespace: namespace,
+			ServiceAccount: oidc.ObjectRef{
+				Name: serviceAccount,
+				UID:  "00000000-0000-0000-0000-000000000000",
+			},
+		},
+	}
+}
+
+// Mint returns a JWT with the claims signed by the signing key
+func (i *Issuer) Mint(claims oidc.Claims) (string, error) {
+	i.mu.RLock()
+	key := i.key(i.signingKid)
+	i.mu.RUnlock()
+
+	header, err := json.Marshal(map[string]string{
+		"alg": key.alg,
+		"kid": key.kid,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal token header")
+	}
+	payload, err := json.Marshal(&claims)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal token claims")
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := key.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (k *signingKey) sign(data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	switch signer := k.signer.(type) {
+	case *rsa.PrivateKey:
+		signature, err := rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, digest[:])
+		return signature, errors.Wrap(err, "failed to sign token")
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, signer, digest[:])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to sign token")
+		}
+		// JWS uses the fixed size concatenation of r and s
+		size := (signer.Curve.Params().BitSize + 7) / 8
+		return append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...), nil
+	default:
+		return nil, errors.Errorf("unsupported signing key type: %T", k.signer)
+	}
+}
+
+func (i *Issuer) key(kid string) *signingKey {
+	for _, key := range i.keys {
+		if key.kid == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+func (i *Issuer) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	documents, err := i.Documents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	serve(w, documents.Discovery.Marshal)
+}
+
+func (i *Issuer) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	documents, err := i.Documents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	serve(w, documents.JWKS.Marshal)
+}
+
+func serve(w http.ResponseWriter, marshal func() (string, error)) {
+	data, err := marshal()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(data))
+}
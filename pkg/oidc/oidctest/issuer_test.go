@@ -0,0 +1,112 @@
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+)
+
+func TestMint(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmES256} {
+		issuer, err := NewIssuer(algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer issuer.Close()
+
+		claims := issuer.Claims("irsa-test", "irsa-test", "sts.amazonaws.com")
+		token, err := issuer.Mint(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			t.Fatalf("expected a JWT with 3 parts, got: %s", token)
+		}
+
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var minted oidc.Claims
+		if err := json.Unmarshal(payload, &minted); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(minted, claims) {
+			t.Errorf("expected: %+v\n, got: %+v\n", claims, minted)
+		}
+		if minted.Subject != "system:serviceaccount:irsa-test:irsa-test" {
+			t.Errorf("expected: system:serviceaccount:irsa-test:irsa-test\n, got: %s\n", minted.Subject)
+		}
+
+		keys, err := oidc.ParsePublicKeysPEM(issuer.PublicKeysPEM())
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verify(keys[0], parts[0]+"."+parts[1], signature) {
+			t.Errorf("%s: token signature does not verify with the published key", algorithm)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	issuer, err := NewIssuer(AlgorithmRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+
+	documents, err := issuer.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldKid := documents.JWKS.Keys[0].Kid
+
+	newKid, err := issuer.AddKey(AlgorithmES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.RemoveKey(oldKid); err == nil {
+		t.Error("expected an error when removing the signing key")
+	}
+	if err := issuer.SignWith(newKid); err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.RemoveKey(oldKid); err != nil {
+		t.Fatal(err)
+	}
+
+	documents, err = issuer.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents.JWKS.Keys) != 1 || documents.JWKS.Keys[0].Kid != newKid {
+		t.Errorf("expected only key %s to be published, got: %+v", newKid, documents.JWKS.Keys)
+	}
+}
+
+func verify(publicKey crypto.PublicKey, signingInput string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		size := len(signature) / 2
+		return ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:]))
+	}
+	return false
+}
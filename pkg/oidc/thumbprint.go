@@ -0,0 +1,102 @@
+package oidc
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Thumbprint is the hex encoded SHA-1 of the certificate, the form the AWS
+// IAM OIDC provider takes
+func Thumbprint(cert *x509.Certificate) string {
+	sum := sha1.Sum(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// IssuerThumbprint picks the certificate AWS expects the thumbprint of from the
+// chain served by the issuer, leaf first: the top intermediate CA, the last
+// certificate before a self-signed root. A leaf served alone is its own
+// thumbprint, a leaf served with its root alone uses the root
+func IssuerThumbprint(chainPEM []byte) (string, error) {
+	chain, err := ParseCertificateChainPEM(chainPEM)
+	if err != nil {
+		return "", err
+	}
+	cert, err := topIntermediate(chain)
+	if err != nil {
+		return "", err
+	}
+	return Thumbprint(cert), nil
+}
+
+func topIntermediate(chain []*x509.Certificate) (*x509.Certificate, error) {
+	for i := 0; i < len(chain)-1; i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return nil, errors.Wrapf(err, "certificate %d (%s) is not signed by the next one (%s), the chain has to be ordered leaf first", i, chain[i].Subject, chain[i+1].Subject)
+		}
+	}
+
+	authorities := chain[1:]
+	if len(authorities) == 0 {
+		return chain[0], nil
+	}
+	if last := authorities[len(authorities)-1]; len(authorities) > 1 && isSelfSigned(last) {
+		authorities = authorities[:len(authorities)-1]
+	}
+	return authorities[len(authorities)-1], nil
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// ParseCertificateChainPEM parses every certificate of a PEM chain keeping
+// their order
+func ParseCertificateChainPEM(chainPEM []byte) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for rest := chainPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse certificate")
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("no certificate found in the chain")
+	}
+	return chain, nil
+}
+
+// FetchCertificateChain returns the certificate chain the issuer serves as
+// PEM, leaf first, the way AWS sees it when the provider is created
+func FetchCertificateChain(client *http.Client, issuerURL string) ([]byte, error) {
+	url := strings.TrimSuffix(issuerURL, "/") + OpenIDDiscoveryPath
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.TLS == nil {
+		return nil, errors.Errorf("%s is not served over HTTPS", url)
+	}
+
+	var chainPEM []byte
+	for _, cert := range resp.TLS.PeerCertificates {
+		chainPEM = append(chainPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return chainPEM, nil
+}
@@ -0,0 +1,97 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCertificate signs a certificate with the parent, self-signed when
+// the parent is nil
+func newTestCertificate(t *testing.T, name string, isCA bool, parent *testCertificate) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificate{cert: cert, key: key}
+}
+
+func toChainPEM(certs ...*testCertificate) []byte {
+	var chainPEM []byte
+	for _, cert := range certs {
+		chainPEM = append(chainPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.cert.Raw})...)
+	}
+	return chainPEM
+}
+
+func TestIssuerThumbprint(t *testing.T) {
+	root := newTestCertificate(t, "root", true, nil)
+	intermediate := newTestCertificate(t, "intermediate", true, root)
+	issuing := newTestCertificate(t, "issuing", true, intermediate)
+	leaf := newTestCertificate(t, "leaf", false, issuing)
+	rootSignedLeaf := newTestCertificate(t, "root signed leaf", false, root)
+
+	tests := map[string]struct {
+		chain    []*testCertificate
+		expected *testCertificate
+	}{
+		"intermediates":           {[]*testCertificate{leaf, issuing, intermediate}, intermediate},
+		"intermediates with root": {[]*testCertificate{leaf, issuing, intermediate, root}, intermediate},
+		"single intermediate":     {[]*testCertificate{leaf, issuing}, issuing},
+		"leaf with root":          {[]*testCertificate{rootSignedLeaf, root}, root},
+		"leaf":                    {[]*testCertificate{leaf}, leaf},
+	}
+	for name, test := range tests {
+		thumbprint, err := IssuerThumbprint(toChainPEM(test.chain...))
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if expected := Thumbprint(test.expected.cert); thumbprint != expected {
+			t.Errorf("%s: expected: %s\n, got: %s\n", name, expected, thumbprint)
+		}
+	}
+
+	for name, chain := range map[string][]byte{
+		"out of order": toChainPEM(leaf, intermediate, issuing),
+		"missing link": toChainPEM(leaf, intermediate),
+		"empty":        nil,
+	} {
+		if _, err := IssuerThumbprint(chain); err == nil {
+			t.Errorf("%s: expected the chain to be rejected", name)
+		}
+	}
+}
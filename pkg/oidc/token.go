@@ -0,0 +1,157 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ServiceAccountSubject is the `sub` claim of tokens issued to a service account
+func ServiceAccountSubject(namespace, serviceAccount string) string {
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccount)
+}
+
+// TokenExpectations are what a token is checked against, the same checks
+// AWS STS does on `AssumeRoleWithWebIdentity`
+type TokenExpectations struct {
+	Issuer   string
+	Audience string
+	// Subject is optional, any service account subject is accepted when empty
+	Subject string
+	// Now defaults to the current time
+	Now time.Time
+}
+
+// ClaimErrors lists every claim of a token that does not match the expectations
+type ClaimErrors []string
+
+func (e ClaimErrors) Error() string {
+	return strings.Join(e, ", ")
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// VerifyToken checks the token signature against the JWKS and then its claims,
+// returning the claims along with `ClaimErrors` naming every wrong claim
+func VerifyToken(token string, keySet JSONWebKeySet, expected TokenExpectations) (*Claims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, errors.Errorf("malformed token: expected 3 parts, got %d", len(parts))
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errors.Wrap(err, "malformed token header")
+	}
+	key, ok := keySet.Key(header.Kid)
+	if !ok {
+		return nil, errors.Errorf("header %q: key %s is not published in the JWKS", "kid", header.Kid)
+	}
+	if header.Alg != key.Alg {
+		return nil, errors.Errorf("header %q: expected %q as published for key %s, got %q", "alg", key.Alg, key.Kid, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed token signature")
+	}
+	if err := verifySignature(key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.Wrap(err, "malformed token claims")
+	}
+	if errs := verifyClaims(&claims, expected); len(errs) > 0 {
+		return &claims, errs
+	}
+	return &claims, nil
+}
+
+func verifyClaims(claims *Claims, expected TokenExpectations) ClaimErrors {
+	now := expected.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	var errs ClaimErrors
+	if claims.Issuer != expected.Issuer {
+		errs = append(errs, fmt.Sprintf("claim %q: expected %q, got %q", "iss", expected.Issuer, claims.Issuer))
+	}
+	if !contains(claims.Audience, expected.Audience) {
+		errs = append(errs, fmt.Sprintf("claim %q: expected %q, got %q", "aud", expected.Audience, claims.Audience))
+	}
+	if expiry := time.Unix(claims.Expiry, 0); !now.Before(expiry) {
+		errs = append(errs, fmt.Sprintf("claim %q: token expired at %s", "exp", expiry.UTC().Format(time.RFC3339)))
+	}
+	if notBefore := time.Unix(claims.NotBefore, 0); now.Before(notBefore) {
+		errs = append(errs, fmt.Sprintf("claim %q: token is not valid before %s", "nbf", notBefore.UTC().Format(time.RFC3339)))
+	}
+	if expected.Subject != "" {
+		if claims.Subject != expected.Subject {
+			errs = append(errs, fmt.Sprintf("claim %q: expected %q, got %q", "sub", expected.Subject, claims.Subject))
+		}
+	} else if fields := strings.Split(claims.Subject, ":"); len(fields) != 4 || claims.Subject != ServiceAccountSubject(fields[2], fields[3]) {
+		errs = append(errs, fmt.Sprintf("claim %q: expected a service account subject, got %q", "sub", claims.Subject))
+	}
+	return errs
+}
+
+func verifySignature(key JSONWebKey, signingInput, signature []byte) error {
+	publicKey, err := key.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	var hashFunc crypto.Hash
+	var hasher hash.Hash
+	switch key.Alg {
+	case "RS256", "ES256":
+		hashFunc, hasher = crypto.SHA256, sha256.New()
+	case "ES384":
+		hashFunc, hasher = crypto.SHA384, sha512.New384()
+	case "ES512":
+		hashFunc, hasher = crypto.SHA512, sha512.New()
+	default:
+		return errors.Errorf("header %q: unsupported signing algorithm %q", "alg", key.Alg)
+	}
+	hasher.Write(signingInput)
+	digest := hasher.Sum(nil)
+
+	valid := false
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(publicKey, hashFunc, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) == 2*size {
+			valid = ecdsa.Verify(publicKey, digest, new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:]))
+		}
+	}
+	if !valid {
+		return errors.Errorf("signature does not verify with key %s", key.Kid)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,134 @@
+package oidc_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc/oidctest"
+)
+
+func TestVerifyToken(t *testing.T) {
+	issuer, err := oidctest.NewIssuer(oidctest.AlgorithmRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+
+	documents, err := oidc.FetchDocuments(issuer.Client(), issuer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := oidc.TokenExpectations{
+		Issuer:   issuer.URL,
+		Audience: "sts.amazonaws.com",
+		Subject:  "system:serviceaccount:irsa-test:irsa-test",
+	}
+
+	valid := issuer.Claims("irsa-test", "irsa-test", "sts.amazonaws.com")
+	expired := valid
+	expired.Expiry = time.Now().Add(-time.Minute).Unix()
+	wrongIssuer := valid
+	wrongIssuer.Issuer = "https://kubernetes.default.svc.cluster.local"
+
+	tests := []struct {
+		claims oidc.Claims
+		claim  string
+	}{
+		{
+			claims: valid,
+		},
+		{
+			claims: issuer.Claims("irsa-test", "irsa-test", "https://kubernetes.default.svc.cluster.local"),
+			claim:  `claim "aud"`,
+		},
+		{
+			claims: expired,
+			claim:  `claim "exp"`,
+		},
+		{
+			claims: wrongIssuer,
+			claim:  `claim "iss"`,
+		},
+		{
+			claims: issuer.Claims("irsa-test", "default", "sts.amazonaws.com"),
+			claim:  `claim "sub"`,
+		},
+	}
+
+	for _, test := range tests {
+		token, err := issuer.Mint(test.claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = oidc.VerifyToken(token, documents.JWKS, expected)
+		if test.claim == "" {
+			if err != nil {
+				t.Error(err)
+			}
+			continue
+		}
+		if _, ok := err.(oidc.ClaimErrors); !ok {
+			t.Errorf("expected claim errors naming %s, got: %v", test.claim, err)
+		} else if !strings.Contains(err.Error(), test.claim) {
+			t.Errorf("expected the error to name %s, got: %s", test.claim, err)
+		}
+	}
+}
+
+func TestVerifyTokenSignature(t *testing.T) {
+	issuer, err := oidctest.NewIssuer(oidctest.AlgorithmES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+
+	documents, err := issuer.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := oidc.TokenExpectations{
+		Issuer:   issuer.URL,
+		Audience: "sts.amazonaws.com",
+	}
+
+	token, err := issuer.Mint(issuer.Claims("irsa-test", "irsa-test", "sts.amazonaws.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oidc.VerifyToken(token, documents.JWKS, expected); err != nil {
+		t.Error(err)
+	}
+
+	// tokens signed with a key that was never published
+	kid, err := issuer.AddKey(oidctest.AlgorithmRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.SignWith(kid); err != nil {
+		t.Fatal(err)
+	}
+	token, err = issuer.Mint(issuer.Claims("irsa-test", "irsa-test", "sts.amazonaws.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oidc.VerifyToken(token, documents.JWKS, expected); err == nil || !strings.Contains(err.Error(), `"kid"`) {
+		t.Errorf("expected an error naming the unpublished kid, got: %v", err)
+	}
+
+	// tampered claims
+	parts := strings.Split(token, ".")
+	other, err := issuer.Mint(issuer.Claims("kube-system", "admin", "sts.amazonaws.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	documents, err = issuer.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := parts[0] + "." + strings.Split(other, ".")[1] + "." + parts[2]
+	if _, err := oidc.VerifyToken(tampered, documents.JWKS, expected); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("expected a signature error, got: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package oidc
+
+// Discovery is the openid configuration served at `OpenIDDiscoveryPath`,
+// fields are in the order the apiserver serves them
+type Discovery struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// JSONWebKey is a public service account verification key, fields are in the
+// order the apiserver serves them
+type JSONWebKey struct {
+	Use string `json:"use"`
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	Alg string `json:"alg"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JSONWebKeySet is the JWKS served at `JWKSDiscoveryPath`
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Documents are the documents hosted for a service account issuer
+type Documents struct {
+	Discovery Discovery
+	JWKS      JSONWebKeySet
+}
+
+// JWKSDiff lists the key ids that differ between two key sets
+type JWKSDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Claims are the claims of a projected service account token
+type Claims struct {
+	Issuer     string           `json:"iss"`
+	Subject    string           `json:"sub"`
+	Audience   []string         `json:"aud"`
+	Expiry     int64            `json:"exp"`
+	IssuedAt   int64            `json:"iat"`
+	NotBefore  int64            `json:"nbf"`
+	Kubernetes KubernetesClaims `json:"kubernetes.io"`
+}
+
+// KubernetesClaims are the claims under the `kubernetes.io` key
+type KubernetesClaims struct {
+	Namespace      string     `json:"namespace"`
+	Pod            *ObjectRef `json:"pod,omitempty"`
+	ServiceAccount ObjectRef  `json:"serviceaccount"`
+}
+
+// ObjectRef references the object a token is bound to
+type ObjectRef struct {
+	Name string `json:"name"`
+	UID  string `json:"uid"`
+}
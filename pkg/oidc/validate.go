@@ -0,0 +1,88 @@
+package oidc
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Validate checks the documents are usable by AWS for the given issuer, so
+// that a broken issuer fails before the documents get published instead of
+// being rejected by AWS at token exchange time
+func (d *Documents) Validate(issuerURL string) error {
+	if err := validateDiscovery(&d.Discovery, issuerURL); err != nil {
+		return errors.Wrap(err, "invalid openid discovery document")
+	}
+	if err := validateJWKS(&d.JWKS, d.Discovery.IDTokenSigningAlgValuesSupported); err != nil {
+		return errors.Wrap(err, "invalid JWKS")
+	}
+	return nil
+}
+
+func validateDiscovery(discovery *Discovery, issuerURL string) error {
+	if discovery.Issuer != issuerURL {
+		return errors.Errorf("field %q: expected %q, got %q", "issuer", issuerURL, discovery.Issuer)
+	}
+	if jwksURI := fmt.Sprintf("%s/%s", issuerURL, KeysJSON); discovery.JWKSURI != jwksURI {
+		return errors.Errorf("field %q: expected %q, got %q", "jwks_uri", jwksURI, discovery.JWKSURI)
+	}
+	if !contains(discovery.ResponseTypesSupported, "id_token") {
+		return errors.Errorf("field %q: must contain %q", "response_types_supported", "id_token")
+	}
+	if len(discovery.SubjectTypesSupported) == 0 {
+		return errors.Errorf("field %q: must not be empty", "subject_types_supported")
+	}
+	if len(discovery.IDTokenSigningAlgValuesSupported) == 0 {
+		return errors.Errorf("field %q: must not be empty", "id_token_signing_alg_values_supported")
+	}
+	return nil
+}
+
+func validateJWKS(keySet *JSONWebKeySet, signingAlgs []string) error {
+	if len(keySet.Keys) == 0 {
+		return errors.Errorf("field %q: must not be empty", "keys")
+	}
+	for i, key := range keySet.Keys {
+		for _, field := range []struct{ name, value string }{
+			{"kid", key.Kid},
+			{"alg", key.Alg},
+			{"use", key.Use},
+			{"kty", key.Kty},
+		} {
+			if field.value == "" {
+				return errors.Errorf("field %q of key %d: must be set", field.name, i)
+			}
+		}
+		if key.Use != "sig" {
+			return errors.Errorf("field %q of key %s: expected %q, got %q", "use", key.Kid, "sig", key.Use)
+		}
+		if !contains(signingAlgs, key.Alg) {
+			return errors.Errorf("field %q of key %s: %q is not listed in %q", "alg", key.Kid, key.Alg, "id_token_signing_alg_values_supported")
+		}
+
+		var required []struct{ name, value string }
+		switch key.Kty {
+		case "RSA":
+			required = []struct{ name, value string }{{"n", key.N}, {"e", key.E}}
+		case "EC":
+			required = []struct{ name, value string }{{"crv", key.Crv}, {"x", key.X}, {"y", key.Y}}
+		default:
+			return errors.Errorf("field %q of key %s: unsupported key type %q", "kty", key.Kid, key.Kty)
+		}
+		for _, field := range required {
+			if field.value == "" {
+				return errors.Errorf("field %q of key %s: must be set", field.name, key.Kid)
+			}
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
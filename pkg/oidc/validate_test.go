@@ -0,0 +1,94 @@
+package oidc
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testIssuerURL     = "https://somedomain"
+	testDiscoveryJSON = `{"issuer":"https://somedomain","jwks_uri":"https://somedomain/keys.json","response_types_supported":["id_token"],"subject_types_supported":["public"],"id_token_signing_alg_values_supported":["RS256"]}`
+	testJWKSJSON      = `{"keys":[{"use":"sig","kty":"RSA","kid":"abc","alg":"RS256","n":"xyz","e":"AQAB"}]}`
+)
+
+func validate(discoveryJSON, jwksJSON string) error {
+	documents, err := ParseDocuments([]byte(discoveryJSON), []byte(jwksJSON))
+	if err != nil {
+		return err
+	}
+	return documents.Validate(testIssuerURL)
+}
+
+func TestValidate(t *testing.T) {
+	if err := validate(testDiscoveryJSON, testJWKSJSON); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		discoveryJSON string
+		jwksJSON      string
+		field         string
+	}{
+		{
+			discoveryJSON: strings.Replace(testDiscoveryJSON, `"issuer":"https://somedomain"`, `"issuer":"https://kubernetes.default.svc.cluster.local"`, 1),
+			jwksJSON:      testJWKSJSON,
+			field:         `"issuer"`,
+		},
+		{
+			discoveryJSON: strings.Replace(testDiscoveryJSON, "https://somedomain/keys.json", "https://172.18.0.2:6443/openid/v1/jwks", 1),
+			jwksJSON:      testJWKSJSON,
+			field:         `"jwks_uri"`,
+		},
+		{
+			discoveryJSON: strings.Replace(testDiscoveryJSON, `"response_types_supported":["id_token"]`, `"response_types_supported":[]`, 1),
+			jwksJSON:      testJWKSJSON,
+			field:         `"response_types_supported"`,
+		},
+		{
+			discoveryJSON: strings.Replace(testDiscoveryJSON, `"subject_types_supported":["public"],`, "", 1),
+			jwksJSON:      testJWKSJSON,
+			field:         `"subject_types_supported"`,
+		},
+		{
+			discoveryJSON: testDiscoveryJSON,
+			jwksJSON:      `{"keys":[]}`,
+			field:         `"keys"`,
+		},
+		{
+			discoveryJSON: testDiscoveryJSON,
+			jwksJSON:      strings.Replace(testJWKSJSON, `"kid":"abc",`, "", 1),
+			field:         `"kid"`,
+		},
+		{
+			discoveryJSON: testDiscoveryJSON,
+			jwksJSON:      strings.Replace(testJWKSJSON, `"alg":"RS256",`, "", 1),
+			field:         `"alg"`,
+		},
+		{
+			discoveryJSON: testDiscoveryJSON,
+			jwksJSON:      strings.Replace(testJWKSJSON, `"use":"sig",`, `"use":"enc",`, 1),
+			field:         `"use"`,
+		},
+		{
+			discoveryJSON: testDiscoveryJSON,
+			jwksJSON:      strings.Replace(testJWKSJSON, `"alg":"RS256"`, `"alg":"ES256"`, 1),
+			field:         `"alg"`,
+		},
+		{
+			discoveryJSON: testDiscoveryJSON,
+			jwksJSON:      strings.Replace(testJWKSJSON, `,"e":"AQAB"`, "", 1),
+			field:         `"e"`,
+		},
+	}
+
+	for _, test := range tests {
+		err := validate(test.discoveryJSON, test.jwksJSON)
+		if err == nil {
+			t.Errorf("expected an error for field %s", test.field)
+		} else if !strings.Contains(err.Error(), test.field) {
+			t.Errorf("expected the error to name field %s, got: %s", test.field, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/frezbo/irsa-anywhere/pkg/apps/irsa"
+	"github.com/frezbo/irsa-anywhere/pkg/apps/sampleapp"
+	"github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/cluster/kind"
+	"github.com/frezbo/irsa-anywhere/pkg/component"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	pulumiprovider "github.com/pulumi/pulumi/sdk/v3/go/pulumi/provider"
+)
+
+// kindClusterArgs are the inputs of a `KindCluster`, everything but the
+// issuer url shapes the nodes and must be known up front
+type kindClusterArgs struct {
+	IssuerURL                      pulumi.StringInput     `pulumi:"issuerUrl"`
+	Audiences                      []string               `pulumi:"audiences"`
+	Topology                       map[string]interface{} `pulumi:"topology"`
+	NodeImage                      string                 `pulumi:"nodeImage"`
+	KubernetesVersion              string                 `pulumi:"kubernetesVersion"`
+	ServiceAccountKeyAlgorithm     string                 `pulumi:"serviceAccountKeyAlgorithm"`
+	ServiceAccountKeyGeneration    int                    `pulumi:"serviceAccountKeyGeneration"`
+	ServiceAccountMaxTokenLifetime string                 `pulumi:"serviceAccountMaxTokenLifetime"`
+}
+
+// webhookArgs are the inputs of a `Webhook`
+type webhookArgs struct {
+	Kubeconfig pulumi.StringInput `pulumi:"kubeconfig"`
+	Audience   string             `pulumi:"audience"`
+}
+
+// workloadRoleArgs are the inputs of the sampleapp `WorkloadRole`
+type workloadRoleArgs struct {
+	IssuerURL       pulumi.StringInput `pulumi:"issuerUrl"`
+	OIDCProviderARN pulumi.StringInput `pulumi:"oidcProviderArn"`
+	Kubeconfig      pulumi.StringInput `pulumi:"kubeconfig"`
+	Audience        string             `pulumi:"audience"`
+}
+
+// construct creates the components of the schema on behalf of programs
+// written in other languages
+func construct(ctx *pulumi.Context, typ, name string, inputs pulumiprovider.ConstructInputs, options pulumi.ResourceOption) (*pulumiprovider.ConstructResult, error) {
+	switch typ {
+	case component.KindClusterType:
+		return constructKindCluster(ctx, name, inputs, options)
+	case component.WebhookType:
+		return constructWebhook(ctx, name, inputs, options)
+	case component.WorkloadRoleType:
+		return constructWorkloadRole(ctx, name, inputs, options)
+	default:
+		return nil, errors.Errorf("unknown resource type %s", typ)
+	}
+}
+
+func constructKindCluster(ctx *pulumi.Context, name string, inputs pulumiprovider.ConstructInputs, options pulumi.ResourceOption) (*pulumiprovider.ConstructResult, error) {
+	args := &kindClusterArgs{}
+	if err := inputs.CopyTo(args); err != nil {
+		return nil, errors.Wrap(err, "failed to read the KindCluster inputs")
+	}
+	if args.IssuerURL == nil {
+		return nil, errors.New("issuerUrl is required")
+	}
+	audiences, err := cluster.NewAudiences(args.Audiences, "")
+	if err != nil {
+		return nil, err
+	}
+	topology, err := kindTopology(args.Topology)
+	if err != nil {
+		return nil, err
+	}
+	signingKey, err := kind.NewSigningKey(args.ServiceAccountKeyAlgorithm, args.ServiceAccountKeyGeneration, args.ServiceAccountMaxTokenLifetime)
+	if err != nil {
+		return nil, err
+	}
+
+	kindCluster, err := component.NewKindCluster(ctx, name, options)
+	if err != nil {
+		return nil, err
+	}
+	backend := kind.NewKindConfig(ctx, name, topology, &kind.NodeImage{
+		Image:             args.NodeImage,
+		KubernetesVersion: args.KubernetesVersion,
+	}, signingKey)
+	created, err := backend.Create(&cluster.Issuer{
+		URL:       args.IssuerURL.ToStringOutput(),
+		Audiences: audiences.APIServer,
+	}, kindCluster)
+	if err != nil {
+		return nil, err
+	}
+	if err := kindCluster.RegisterOutputs(ctx, created.Name, created.Kubeconfig, created.OIDCConfig.DiscoveryJSON(), created.OIDCConfig.JWKSJSON()); err != nil {
+		return nil, err
+	}
+	return pulumiprovider.NewConstructResult(kindCluster)
+}
+
+// kindTopology overlays the topology input on the default topology, the
+// same way the `kindTopology` stack config is read
+func kindTopology(input map[string]interface{}) (*kind.Topology, error) {
+	topology := kind.DefaultTopology()
+	if input == nil {
+		return topology, nil
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read topology")
+	}
+	if err := json.Unmarshal(raw, topology); err != nil {
+		return nil, errors.Wrap(err, "failed to read topology")
+	}
+	return topology, nil
+}
+
+func constructWebhook(ctx *pulumi.Context, name string, inputs pulumiprovider.ConstructInputs, options pulumi.ResourceOption) (*pulumiprovider.ConstructResult, error) {
+	args := &webhookArgs{}
+	if err := inputs.CopyTo(args); err != nil {
+		return nil, errors.Wrap(err, "failed to read the Webhook inputs")
+	}
+	if args.Kubeconfig == nil {
+		return nil, errors.New("kubeconfig is required")
+	}
+	if args.Audience == "" {
+		args.Audience = cluster.STSAudience
+	}
+
+	webhook, err := component.NewWebhook(ctx, name, &component.WebhookArgs{
+		Namespace:        irsa.Namespace,
+		AnnotationPrefix: irsa.AnnotationPrefix,
+		Audience:         args.Audience,
+	}, nil, options)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := irsa.NewIRSAConfig(ctx, name, args.Kubeconfig, args.Audience, webhook).Create(); err != nil {
+		return nil, err
+	}
+	if err := webhook.RegisterOutputs(ctx); err != nil {
+		return nil, err
+	}
+	return pulumiprovider.NewConstructResult(webhook)
+}
+
+func constructWorkloadRole(ctx *pulumi.Context, name string, inputs pulumiprovider.ConstructInputs, options pulumi.ResourceOption) (*pulumiprovider.ConstructResult, error) {
+	args := &workloadRoleArgs{}
+	if err := inputs.CopyTo(args); err != nil {
+		return nil, errors.Wrap(err, "failed to read the WorkloadRole inputs")
+	}
+	for input, value := range map[string]pulumi.StringInput{
+		"issuerUrl":       args.IssuerURL,
+		"oidcProviderArn": args.OIDCProviderARN,
+		"kubeconfig":      args.Kubeconfig,
+	} {
+		if value == nil {
+			return nil, errors.Errorf("%s is required", input)
+		}
+	}
+	if args.Audience == "" {
+		args.Audience = cluster.STSAudience
+	}
+
+	workloadRole, err := component.NewWorkloadRole(ctx, name, &component.WorkloadRoleArgs{
+		Namespace:      sampleapp.Namespace,
+		ServiceAccount: sampleapp.ServiceAccountName,
+	}, nil, options)
+	if err != nil {
+		return nil, err
+	}
+	// IAM condition keys are prefixed with the issuer url without the scheme
+	oidcEndpoint := args.IssuerURL.ToStringOutput().ApplyT(func(issuerURL string) string {
+		return strings.TrimPrefix(issuerURL, "https://")
+	}).(pulumi.StringOutput)
+	if _, err := sampleapp.NewSampleAppConfig(ctx, name, oidcEndpoint, args.OIDCProviderARN, args.Kubeconfig, args.Audience, workloadRole, nil).Create(); err != nil {
+		return nil, err
+	}
+	return pulumiprovider.NewConstructResult(workloadRole)
+}
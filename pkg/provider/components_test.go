@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/frezbo/irsa-anywhere/pkg/component"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+type schemaResource struct {
+	IsComponent     bool                       `json:"isComponent"`
+	InputProperties map[string]json.RawMessage `json:"inputProperties"`
+	Properties      map[string]json.RawMessage `json:"properties"`
+}
+
+func pulumiTags(v interface{}) []string {
+	var tags []string
+	typ := reflect.TypeOf(v).Elem()
+	for i := 0; i < typ.NumField(); i++ {
+		if tag, ok := typ.Field(i).Tag.Lookup("pulumi"); ok {
+			tags = append(tags, strings.Split(tag, ",")[0])
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func propertyNames(properties map[string]json.RawMessage) []string {
+	var names []string
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSchemaComponents(t *testing.T) {
+	resp, err := newProvider("0.0.1").GetSchema(context.Background(), &pulumirpc.GetSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		Name      string                    `json:"name"`
+		Resources map[string]schemaResource `json:"resources"`
+	}
+	if err := json.Unmarshal([]byte(resp.GetSchema()), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Name != "irsa" {
+		t.Errorf("expected: %s\n, got: %s\n", "irsa", spec.Name)
+	}
+
+	tests := []struct {
+		typ     string
+		inputs  interface{}
+		outputs interface{}
+	}{
+		{
+			typ:     component.KindClusterType,
+			inputs:  &kindClusterArgs{},
+			outputs: &component.KindCluster{},
+		},
+		{
+			typ:     component.WebhookType,
+			inputs:  &webhookArgs{},
+			outputs: &component.Webhook{},
+		},
+		{
+			typ:     component.WorkloadRoleType,
+			inputs:  &workloadRoleArgs{},
+			outputs: &component.WorkloadRole{},
+		},
+	}
+
+	for _, tt := range tests {
+		resource, ok := spec.Resources[tt.typ]
+		if !ok || !resource.IsComponent {
+			t.Errorf("expected %s to be a component of the schema", tt.typ)
+			continue
+		}
+		if expected, got := pulumiTags(tt.inputs), propertyNames(resource.InputProperties); !reflect.DeepEqual(expected, got) {
+			t.Errorf("expected: %s\n, got: %s\n", expected, got)
+		}
+		if expected, got := pulumiTags(tt.outputs), propertyNames(resource.Properties); !reflect.DeepEqual(expected, got) {
+			t.Errorf("expected: %s\n, got: %s\n", expected, got)
+		}
+	}
+}
+
+func TestKindTopology(t *testing.T) {
+	topology, err := kindTopology(map[string]interface{}{
+		"worker": map[string]interface{}{
+			"count":  2,
+			"labels": map[string]interface{}{"ingress-ready": "true"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topology.ControlPlane.Count != 1 {
+		t.Errorf("expected: %d\n, got: %d\n", 1, topology.ControlPlane.Count)
+	}
+	if topology.Worker.Count != 2 || topology.Worker.Labels["ingress-ready"] != "true" {
+		t.Errorf("expected: %s\n, got: %v\n", "2 labelled workers", topology.Worker)
+	}
+
+	if _, err := kindTopology(map[string]interface{}{"worker": map[string]interface{}{"count": "two"}}); err == nil {
+		t.Error("expected an invalid topology to be rejected")
+	}
+}
@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"sort"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/cluster/kind"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+const (
+	documentsType = tokens.Type(cluster.OIDCDocumentsType)
+
+	// documentsTimeout gives a restarted apiserver time to come back with
+	// the expected keys
+	documentsTimeout       = 2 * time.Minute
+	documentsRetryInterval = 5 * time.Second
+)
+
+// documentsInputKeys are the inputs of an `OIDCDocuments` resource, any
+// change updates the documents in place
+var documentsInputKeys = []resource.PropertyKey{
+	cluster.OIDCDocumentsKubeconfig,
+	cluster.OIDCDocumentsIssuer,
+	cluster.OIDCDocumentsExpectedJWKS,
+	cluster.OIDCDocumentsKindCluster,
+	cluster.OIDCDocumentsKindNodeProvider,
+}
+
+// documentsGetter reads the documents served by the cluster of a kubeconfig
+type documentsGetter func(kubeconfig string) (*oidc.Documents, error)
+
+// controlPlaneVerifier checks every control plane node of a kind cluster
+// serves the JWKS
+type controlPlaneVerifier func(clusterName, nodeProvider string, jwks oidc.JSONWebKeySet) error
+
+func getClusterDocuments(kubeconfig string) (*oidc.Documents, error) {
+	return cluster.GetOIDCConfig(kubeconfig, "")
+}
+
+func checkDocumentsInputs(inputs resource.PropertyMap) []*pulumirpc.CheckFailure {
+	var failures []*pulumirpc.CheckFailure
+	for _, key := range documentsInputKeys {
+		value, ok := inputs[key]
+		switch {
+		case !ok && key == cluster.OIDCDocumentsKubeconfig:
+			failures = append(failures, &pulumirpc.CheckFailure{Property: string(key), Reason: "missing required property"})
+		case ok && !value.IsString() && !value.IsComputed():
+			failures = append(failures, &pulumirpc.CheckFailure{Property: string(key), Reason: "must be a string"})
+		case ok && key == cluster.OIDCDocumentsExpectedJWKS && value.IsString():
+			if _, err := oidc.ParseJWKS([]byte(value.StringValue())); err != nil {
+				failures = append(failures, &pulumirpc.CheckFailure{Property: string(key), Reason: err.Error()})
+			}
+		case ok && key == cluster.OIDCDocumentsKindNodeProvider && value.IsString():
+			if err := kind.ValidateNodeProvider(value.StringValue()); err != nil {
+				failures = append(failures, &pulumirpc.CheckFailure{Property: string(key), Reason: err.Error()})
+			}
+		}
+	}
+	return failures
+}
+
+// diffDocumentsInputs lists the inputs that changed, outputs are left out
+// since a refresh may have read newer documents from the cluster. A refreshed
+// JWKS is only a change when it is not the expected one
+func diffDocumentsInputs(olds, news resource.PropertyMap, ignoreChanges []string) []string {
+	ignored := map[string]bool{}
+	for _, key := range ignoreChanges {
+		ignored[key] = true
+	}
+
+	var changed []string
+	for _, key := range documentsInputKeys {
+		if ignored[string(key)] {
+			continue
+		}
+		if !olds[key].DeepEquals(news[key]) || news[key].ContainsUnknowns() {
+			changed = append(changed, string(key))
+		}
+	}
+	if !ignored[cluster.OIDCDocumentsJWKS] && servesOtherJWKS(olds, news) {
+		changed = append(changed, cluster.OIDCDocumentsJWKS)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// servesOtherJWKS is true when the JWKS last read from the cluster is not the
+// expected one
+func servesOtherJWKS(olds, news resource.PropertyMap) bool {
+	served, expected := olds[cluster.OIDCDocumentsJWKS], news[cluster.OIDCDocumentsExpectedJWKS]
+	if !served.IsString() || !expected.IsString() {
+		return false
+	}
+	servedJWKS, err := oidc.ParseJWKS([]byte(served.StringValue()))
+	if err != nil {
+		return true
+	}
+	expectedJWKS, err := oidc.ParseJWKS([]byte(expected.StringValue()))
+	return err != nil || !servedJWKS.Equal(*expectedJWKS)
+}
+
+func documentsInputs(properties resource.PropertyMap) resource.PropertyMap {
+	inputs := resource.PropertyMap{}
+	for _, key := range documentsInputKeys {
+		if value, ok := properties[key]; ok {
+			inputs[key] = value
+		}
+	}
+	return inputs
+}
+
+// readDocuments fetches the documents from the cluster, the issuer they are
+// served for is the resource id. With wait set the cluster has to serve the
+// expected JWKS, otherwise the documents are read as they are served
+func (p *provider) readDocuments(inputs resource.PropertyMap, wait bool) (string, resource.PropertyMap, error) {
+	kubeconfig, ok := inputs[cluster.OIDCDocumentsKubeconfig]
+	if !ok || !kubeconfig.IsString() {
+		return "", nil, errors.Errorf("property %q must be a string", cluster.OIDCDocumentsKubeconfig)
+	}
+	var expectedJWKS *oidc.JSONWebKeySet
+	if expected, ok := inputs[cluster.OIDCDocumentsExpectedJWKS]; ok && expected.IsString() && wait {
+		var err error
+		if expectedJWKS, err = oidc.ParseJWKS([]byte(expected.StringValue())); err != nil {
+			return "", nil, err
+		}
+	}
+	documents, err := p.waitForDocuments(kubeconfig.StringValue(), expectedJWKS)
+	if err != nil {
+		return "", nil, err
+	}
+	if issuer, ok := inputs[cluster.OIDCDocumentsIssuer]; ok && issuer.IsString() {
+		if err := documents.Validate(issuer.StringValue()); err != nil {
+			return "", nil, errors.Wrap(err, "cluster serves documents for another issuer")
+		}
+	}
+	if kindCluster, ok := inputs[cluster.OIDCDocumentsKindCluster]; ok && kindCluster.IsString() {
+		var nodeProvider string
+		if value, ok := inputs[cluster.OIDCDocumentsKindNodeProvider]; ok && value.IsString() {
+			nodeProvider = value.StringValue()
+		}
+		if err := p.verifyControlPlanes(kindCluster.StringValue(), nodeProvider, documents.JWKS); err != nil {
+			return "", nil, err
+		}
+	}
+
+	discoveryJSON, err := documents.Discovery.Marshal()
+	if err != nil {
+		return "", nil, err
+	}
+	jwksJSON, err := documents.JWKS.Marshal()
+	if err != nil {
+		return "", nil, err
+	}
+	outputs := documentsInputs(inputs)
+	outputs[cluster.OIDCDocumentsDiscovery] = resource.NewStringProperty(discoveryJSON)
+	outputs[cluster.OIDCDocumentsJWKS] = resource.NewStringProperty(jwksJSON)
+	return documents.Discovery.Issuer, outputs, nil
+}
+
+// waitForDocuments retries until the cluster serves the expected JWKS, a nil
+// expectedJWKS reads the documents once
+func (p *provider) waitForDocuments(kubeconfig string, expectedJWKS *oidc.JSONWebKeySet) (*oidc.Documents, error) {
+	deadline := time.Now().Add(p.documentsTimeout)
+	for {
+		documents, err := p.getDocuments(kubeconfig)
+		if err == nil && expectedJWKS != nil && !documents.JWKS.Equal(*expectedJWKS) {
+			diff := expectedJWKS.Diff(documents.JWKS)
+			err = errors.Errorf("cluster serves a different JWKS than the expected one, unexpected keys: %v, missing keys: %v, changed keys: %v", diff.Added, diff.Removed, diff.Changed)
+		}
+		if err == nil || expectedJWKS == nil || time.Now().After(deadline) {
+			return documents, err
+		}
+		time.Sleep(p.documentsRetryInterval)
+	}
+}
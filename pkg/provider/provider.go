@@ -0,0 +1,300 @@
+package provider
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/cluster/kind"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
+	pulumiprovider "github.com/pulumi/pulumi/sdk/v3/go/pulumi/provider"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// schema describes the resources of the `irsa` package, SDKs for other
+// languages are generated from it
+//
+//go:embed schema.json
+var schema string
+
+// Serve runs the provider plugin the pulumi engine talks to, the port it
+// listens on is written to stdout for the engine to connect. Components are
+// constructed by registering their children with the engine at engineAddress
+func Serve(version, engineAddress string) error {
+	engineConn, err := grpc.Dial(engineAddress, grpc.WithInsecure(), rpcutil.GrpcChannelOptions())
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to the engine")
+	}
+	defer engineConn.Close()
+
+	p := newProvider(version)
+	p.engineConn = engineConn
+	port, done, err := rpcutil.Serve(0, nil, []func(*grpc.Server) error{
+		func(srv *grpc.Server) error {
+			pulumirpc.RegisterResourceProviderServer(srv, p)
+			return nil
+		},
+	}, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to serve the provider")
+	}
+	fmt.Println(port)
+	return <-done
+}
+
+// provider manages the resources of the `irsa` package, the documents served
+// by a cluster and the components of the schema
+type provider struct {
+	pulumirpc.UnimplementedResourceProviderServer
+
+	version    string
+	engineConn *grpc.ClientConn
+	// getDocuments, verifyControlPlanes and the timings are swapped out in tests
+	getDocuments           documentsGetter
+	verifyControlPlanes    controlPlaneVerifier
+	documentsTimeout       time.Duration
+	documentsRetryInterval time.Duration
+	// now is when a key rotation moves on
+	now func() time.Time
+}
+
+func newProvider(version string) *provider {
+	return &provider{
+		version:                version,
+		getDocuments:           getClusterDocuments,
+		verifyControlPlanes:    kind.VerifyControlPlaneJWKS,
+		documentsTimeout:       documentsTimeout,
+		documentsRetryInterval: documentsRetryInterval,
+		now:                    time.Now,
+	}
+}
+
+func (p *provider) GetPluginInfo(context.Context, *emptypb.Empty) (*pulumirpc.PluginInfo, error) {
+	return &pulumirpc.PluginInfo{Version: p.version}, nil
+}
+
+func (p *provider) GetSchema(_ context.Context, req *pulumirpc.GetSchemaRequest) (*pulumirpc.GetSchemaResponse, error) {
+	if req.GetVersion() != 0 {
+		return nil, errors.Errorf("unsupported schema version %d", req.GetVersion())
+	}
+	return &pulumirpc.GetSchemaResponse{Schema: schema}, nil
+}
+
+func (p *provider) CheckConfig(_ context.Context, req *pulumirpc.CheckRequest) (*pulumirpc.CheckResponse, error) {
+	return &pulumirpc.CheckResponse{Inputs: req.GetNews()}, nil
+}
+
+func (p *provider) DiffConfig(context.Context, *pulumirpc.DiffRequest) (*pulumirpc.DiffResponse, error) {
+	return &pulumirpc.DiffResponse{Changes: pulumirpc.DiffResponse_DIFF_NONE}, nil
+}
+
+// Configure leaves secrets to the engine, it marks the outputs matching
+// secret inputs as secret again
+func (p *provider) Configure(context.Context, *pulumirpc.ConfigureRequest) (*pulumirpc.ConfigureResponse, error) {
+	return &pulumirpc.ConfigureResponse{SupportsPreview: true}, nil
+}
+
+func (p *provider) Check(_ context.Context, req *pulumirpc.CheckRequest) (*pulumirpc.CheckResponse, error) {
+	resourceType, err := checkType(req.GetUrn())
+	if err != nil {
+		return nil, err
+	}
+	news, err := unmarshalProperties(req.GetNews())
+	if err != nil {
+		return nil, err
+	}
+	failures := checkDocumentsInputs
+	if resourceType == rotationType {
+		failures = checkRotationInputs
+	}
+	return &pulumirpc.CheckResponse{
+		Inputs:   req.GetNews(),
+		Failures: failures(news),
+	}, nil
+}
+
+func (p *provider) Diff(_ context.Context, req *pulumirpc.DiffRequest) (*pulumirpc.DiffResponse, error) {
+	resourceType, err := checkType(req.GetUrn())
+	if err != nil {
+		return nil, err
+	}
+	olds, err := unmarshalProperties(req.GetOlds())
+	if err != nil {
+		return nil, err
+	}
+	news, err := unmarshalProperties(req.GetNews())
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	if resourceType == rotationType {
+		if changed, err = p.diffRotation(olds, news); err != nil {
+			return nil, err
+		}
+	} else {
+		changed = diffDocumentsInputs(olds, news, req.GetIgnoreChanges())
+	}
+	if len(changed) == 0 {
+		return &pulumirpc.DiffResponse{Changes: pulumirpc.DiffResponse_DIFF_NONE}, nil
+	}
+	detailedDiff := map[string]*pulumirpc.PropertyDiff{}
+	for _, key := range changed {
+		// the served JWKS and the rotation phase are outputs moving on
+		// without their inputs changing
+		inputDiff := key != cluster.OIDCDocumentsJWKS && key != kind.SigningKeyRotationPhase
+		detailedDiff[key] = &pulumirpc.PropertyDiff{Kind: pulumirpc.PropertyDiff_UPDATE, InputDiff: inputDiff}
+	}
+	return &pulumirpc.DiffResponse{
+		Changes:         pulumirpc.DiffResponse_DIFF_SOME,
+		Diffs:           changed,
+		DetailedDiff:    detailedDiff,
+		HasDetailedDiff: true,
+	}, nil
+}
+
+func (p *provider) Create(_ context.Context, req *pulumirpc.CreateRequest) (*pulumirpc.CreateResponse, error) {
+	resourceType, err := checkType(req.GetUrn())
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := unmarshalProperties(req.GetProperties())
+	if err != nil {
+		return nil, err
+	}
+	if resourceType == rotationType {
+		if inputs.ContainsUnknowns() {
+			return &pulumirpc.CreateResponse{Properties: req.GetProperties()}, nil
+		}
+		outputs, err := createRotation(inputs)
+		if err != nil {
+			return nil, err
+		}
+		properties, err := marshalProperties(outputs)
+		return &pulumirpc.CreateResponse{Id: resource.URN(req.GetUrn()).Name().String(), Properties: properties}, err
+	}
+	if req.GetPreview() {
+		return &pulumirpc.CreateResponse{Properties: req.GetProperties()}, nil
+	}
+
+	id, outputs, err := p.readDocuments(inputs, true)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := marshalProperties(outputs)
+	return &pulumirpc.CreateResponse{Id: id, Properties: properties}, err
+}
+
+// Read fetches the documents the cluster serves now, a refresh picks up keys
+// rotated outside of pulumi and a JWKS other than the expected one shows up
+// as a change on the next update. The rotation state only lives in the stack,
+// so it is read back as it is
+func (p *provider) Read(_ context.Context, req *pulumirpc.ReadRequest) (*pulumirpc.ReadResponse, error) {
+	resourceType, err := checkType(req.GetUrn())
+	if err != nil {
+		return nil, err
+	}
+	if resourceType == rotationType {
+		return &pulumirpc.ReadResponse{Id: req.GetId(), Properties: req.GetProperties(), Inputs: req.GetInputs()}, nil
+	}
+	inputs, err := unmarshalProperties(req.GetInputs())
+	if err != nil {
+		return nil, err
+	}
+	// resources imported without inputs carry them in their state
+	if len(inputs) == 0 {
+		if inputs, err = unmarshalProperties(req.GetProperties()); err != nil {
+			return nil, err
+		}
+		inputs = documentsInputs(inputs)
+	}
+
+	id, outputs, err := p.readDocuments(inputs, false)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := marshalProperties(outputs)
+	if err != nil {
+		return nil, err
+	}
+	inputsStruct, err := marshalProperties(inputs)
+	return &pulumirpc.ReadResponse{Id: id, Properties: properties, Inputs: inputsStruct}, err
+}
+
+func (p *provider) Update(_ context.Context, req *pulumirpc.UpdateRequest) (*pulumirpc.UpdateResponse, error) {
+	resourceType, err := checkType(req.GetUrn())
+	if err != nil {
+		return nil, err
+	}
+	news, err := unmarshalProperties(req.GetNews())
+	if err != nil {
+		return nil, err
+	}
+	if resourceType == rotationType {
+		if news.ContainsUnknowns() {
+			return &pulumirpc.UpdateResponse{Properties: req.GetNews()}, nil
+		}
+		olds, err := unmarshalProperties(req.GetOlds())
+		if err != nil {
+			return nil, err
+		}
+		outputs, err := p.updateRotation(olds, news)
+		if err != nil {
+			return nil, err
+		}
+		properties, err := marshalProperties(outputs)
+		return &pulumirpc.UpdateResponse{Properties: properties}, err
+	}
+	if req.GetPreview() {
+		return &pulumirpc.UpdateResponse{Properties: req.GetNews()}, nil
+	}
+
+	_, outputs, err := p.readDocuments(news, true)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := marshalProperties(outputs)
+	return &pulumirpc.UpdateResponse{Properties: properties}, err
+}
+
+// Delete leaves the cluster alone, the documents go away with it and the
+// rotation state with the stack
+func (p *provider) Delete(_ context.Context, req *pulumirpc.DeleteRequest) (*emptypb.Empty, error) {
+	_, err := checkType(req.GetUrn())
+	return &emptypb.Empty{}, err
+}
+
+// Construct creates a component of the schema, its children are registered
+// with the engine the same way a program registers them
+func (p *provider) Construct(ctx context.Context, req *pulumirpc.ConstructRequest) (*pulumirpc.ConstructResponse, error) {
+	return pulumiprovider.Construct(ctx, req, p.engineConn, construct)
+}
+
+func (p *provider) Cancel(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func checkType(urn string) (tokens.Type, error) {
+	resourceType := resource.URN(urn).Type()
+	if resourceType != documentsType && resourceType != rotationType {
+		return "", errors.Errorf("unknown resource type %s", resourceType)
+	}
+	return resourceType, nil
+}
+
+func unmarshalProperties(properties *structpb.Struct) (resource.PropertyMap, error) {
+	return plugin.UnmarshalProperties(properties, plugin.MarshalOptions{KeepUnknowns: true, SkipNulls: true})
+}
+
+func marshalProperties(properties resource.PropertyMap) (*structpb.Struct, error) {
+	return plugin.MarshalProperties(properties, plugin.MarshalOptions{KeepUnknowns: true, SkipNulls: true})
+}
@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc/oidctest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const testURN = "urn:pulumi:dev::irsa-anywhere::irsa:cluster:kind-aws$irsa:index:OIDCDocuments::kind-aws"
+
+func testProvider(t *testing.T) (*provider, *oidctest.Issuer) {
+	t.Helper()
+	issuer, err := oidctest.NewIssuer(oidctest.AlgorithmRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newProvider("0.0.1")
+	p.getDocuments = func(string) (*oidc.Documents, error) {
+		return issuer.Documents()
+	}
+	return p, issuer
+}
+
+func mustMarshal(t *testing.T, properties resource.PropertyMap) *structpb.Struct {
+	t.Helper()
+	marshalled, err := marshalProperties(properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return marshalled
+}
+
+func TestCheck(t *testing.T) {
+	p := newProvider("0.0.1")
+	resp, err := p.Check(context.Background(), &pulumirpc.CheckRequest{
+		Urn: testURN,
+		News: mustMarshal(t, resource.PropertyMap{
+			cluster.OIDCDocumentsIssuer:           resource.NewNumberProperty(1),
+			cluster.OIDCDocumentsKindNodeProvider: resource.NewStringProperty("nerdctl"),
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed []string
+	for _, failure := range resp.GetFailures() {
+		failed = append(failed, failure.GetProperty())
+	}
+	expected := []string{cluster.OIDCDocumentsKubeconfig, cluster.OIDCDocumentsIssuer, cluster.OIDCDocumentsKindNodeProvider}
+	if !reflect.DeepEqual(failed, expected) {
+		t.Errorf("expected: %s\n, got: %s\n", expected, failed)
+	}
+
+	if _, err := p.Check(context.Background(), &pulumirpc.CheckRequest{Urn: "urn:pulumi:dev::irsa-anywhere::irsa:index:Unknown::kind-aws"}); err == nil {
+		t.Error("expected an unknown resource type to be rejected")
+	}
+}
+
+func TestDiffDocumentsInputs(t *testing.T) {
+	olds := resource.PropertyMap{
+		cluster.OIDCDocumentsKubeconfig: resource.NewStringProperty("kubeconfig"),
+		cluster.OIDCDocumentsIssuer:     resource.NewStringProperty("https://somedomain"),
+		cluster.OIDCDocumentsJWKS:       resource.NewStringProperty(`{"keys":[]}`),
+	}
+
+	// refreshed outputs are not a reason to update
+	news := documentsInputs(olds)
+	if changed := diffDocumentsInputs(olds, news, nil); len(changed) != 0 {
+		t.Errorf("expected no changes, got: %s\n", changed)
+	}
+
+	news[cluster.OIDCDocumentsIssuer] = resource.NewStringProperty("https://otherdomain")
+	news[cluster.OIDCDocumentsKubeconfig] = resource.MakeComputed(resource.NewStringProperty(""))
+	expected := []string{cluster.OIDCDocumentsIssuer, cluster.OIDCDocumentsKubeconfig}
+	if changed := diffDocumentsInputs(olds, news, nil); !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected: %s\n, got: %s\n", expected, changed)
+	}
+
+	expected = []string{cluster.OIDCDocumentsKubeconfig}
+	if changed := diffDocumentsInputs(olds, news, []string{cluster.OIDCDocumentsIssuer}); !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected: %s\n, got: %s\n", expected, changed)
+	}
+}
+
+func TestDiffServedJWKS(t *testing.T) {
+	issuer, err := oidctest.NewIssuer(oidctest.AlgorithmRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+	served, err := issuer.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	servedJWKS, err := served.JWKS.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	olds := resource.PropertyMap{
+		cluster.OIDCDocumentsKubeconfig:   resource.NewStringProperty("kubeconfig"),
+		cluster.OIDCDocumentsExpectedJWKS: resource.NewStringProperty(servedJWKS),
+		cluster.OIDCDocumentsJWKS:         resource.NewStringProperty(servedJWKS),
+	}
+	if changed := diffDocumentsInputs(olds, documentsInputs(olds), nil); len(changed) != 0 {
+		t.Errorf("expected no changes, got: %s\n", changed)
+	}
+
+	// a refresh read a JWKS other than the expected one
+	olds[cluster.OIDCDocumentsJWKS] = resource.NewStringProperty(`{"keys":[]}`)
+	expected := []string{cluster.OIDCDocumentsJWKS}
+	if changed := diffDocumentsInputs(olds, documentsInputs(olds), nil); !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected: %s\n, got: %s\n", expected, changed)
+	}
+}
+
+func TestCreateWaitsForExpectedJWKS(t *testing.T) {
+	p, issuer := testProvider(t)
+	defer issuer.Close()
+	p.documentsTimeout = 100 * time.Millisecond
+	p.documentsRetryInterval = time.Millisecond
+
+	// the apiserver still serves the previous keys right after a restart
+	stale, err := issuer.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := issuer.AddKey(oidctest.AlgorithmES256); err != nil {
+		t.Fatal(err)
+	}
+	reads := 0
+	p.getDocuments = func(string) (*oidc.Documents, error) {
+		reads++
+		if reads == 1 {
+			return stale, nil
+		}
+		return issuer.Documents()
+	}
+	var verified []string
+	p.verifyControlPlanes = func(clusterName, nodeProvider string, jwks oidc.JSONWebKeySet) error {
+		verified = append(verified, clusterName, nodeProvider)
+		return nil
+	}
+
+	expected, err := issuer.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJWKS, err := expected.JWKS.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := resource.PropertyMap{
+		cluster.OIDCDocumentsKubeconfig:       resource.NewStringProperty("kubeconfig"),
+		cluster.OIDCDocumentsIssuer:           resource.NewStringProperty(issuer.URL),
+		cluster.OIDCDocumentsExpectedJWKS:     resource.NewStringProperty(expectedJWKS),
+		cluster.OIDCDocumentsKindCluster:      resource.NewStringProperty("kind-aws"),
+		cluster.OIDCDocumentsKindNodeProvider: resource.NewStringProperty("podman"),
+	}
+	created, err := p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: testURN, Properties: mustMarshal(t, inputs)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reads != 2 {
+		t.Errorf("expected the documents to be read again until the expected JWKS is served, got %d reads", reads)
+	}
+	if expected := []string{"kind-aws", "podman"}; !reflect.DeepEqual(verified, expected) {
+		t.Errorf("expected: %s\n, got: %s\n", expected, verified)
+	}
+	assertServedDocuments(t, issuer, created.GetProperties())
+
+	inputs[cluster.OIDCDocumentsExpectedJWKS] = resource.NewStringProperty(`{"keys":[]}`)
+	if _, err := p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: testURN, Properties: mustMarshal(t, inputs)}); err == nil {
+		t.Error("expected a JWKS other than the expected one to be rejected")
+	}
+}
+
+func TestCreateAndRead(t *testing.T) {
+	p, issuer := testProvider(t)
+	defer issuer.Close()
+
+	inputs := resource.PropertyMap{
+		cluster.OIDCDocumentsKubeconfig: resource.NewStringProperty("kubeconfig"),
+		cluster.OIDCDocumentsIssuer:     resource.NewStringProperty(issuer.URL),
+	}
+	created, err := p.Create(context.Background(), &pulumirpc.CreateRequest{
+		Urn:        testURN,
+		Properties: mustMarshal(t, inputs),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.GetId() != issuer.URL {
+		t.Errorf("expected: %s\n, got: %s\n", issuer.URL, created.GetId())
+	}
+	assertServedDocuments(t, issuer, created.GetProperties())
+
+	// keys rotated outside of pulumi are picked up on refresh
+	if _, err := issuer.AddKey(oidctest.AlgorithmES256); err != nil {
+		t.Fatal(err)
+	}
+	read, err := p.Read(context.Background(), &pulumirpc.ReadRequest{
+		Id:         created.GetId(),
+		Urn:        testURN,
+		Properties: created.GetProperties(),
+		Inputs:     mustMarshal(t, inputs),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertServedDocuments(t, issuer, read.GetProperties())
+
+	inputs[cluster.OIDCDocumentsIssuer] = resource.NewStringProperty("https://otherdomain")
+	if _, err := p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: testURN, Properties: mustMarshal(t, inputs)}); err == nil {
+		t.Error("expected documents served for another issuer to be rejected")
+	}
+}
+
+func assertServedDocuments(t *testing.T, issuer *oidctest.Issuer, properties *structpb.Struct) {
+	t.Helper()
+	outputs, err := unmarshalProperties(properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	documents, err := oidc.ParseDocuments([]byte(outputs[cluster.OIDCDocumentsDiscovery].StringValue()), []byte(outputs[cluster.OIDCDocumentsJWKS].StringValue()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := issuer.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !documents.Equal(*expected) {
+		t.Errorf("expected: %+v\n, got: %+v\n", *expected, *documents)
+	}
+}
@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"sort"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/cluster/kind"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+const rotationType = tokens.Type(kind.SigningKeyRotationType)
+
+// rotationInputKeys are the inputs of a `SigningKeyRotation` resource
+var rotationInputKeys = []resource.PropertyKey{
+	kind.SigningKeyRotationGeneration,
+	kind.SigningKeyRotationMaxTokenLifetime,
+}
+
+func checkRotationInputs(inputs resource.PropertyMap) []*pulumirpc.CheckFailure {
+	var failures []*pulumirpc.CheckFailure
+	generation, ok := inputs[kind.SigningKeyRotationGeneration]
+	switch {
+	case !ok:
+		failures = append(failures, &pulumirpc.CheckFailure{Property: kind.SigningKeyRotationGeneration, Reason: "missing required property"})
+	case generation.IsComputed():
+	case !generation.IsNumber() || generation.NumberValue() < 0 || generation.NumberValue() != float64(int(generation.NumberValue())):
+		failures = append(failures, &pulumirpc.CheckFailure{Property: kind.SigningKeyRotationGeneration, Reason: "must be a non negative integer"})
+	}
+
+	maxTokenLifetime, ok := inputs[kind.SigningKeyRotationMaxTokenLifetime]
+	switch {
+	case !ok:
+		failures = append(failures, &pulumirpc.CheckFailure{Property: kind.SigningKeyRotationMaxTokenLifetime, Reason: "missing required property"})
+	case maxTokenLifetime.IsComputed():
+	case !maxTokenLifetime.IsString():
+		failures = append(failures, &pulumirpc.CheckFailure{Property: kind.SigningKeyRotationMaxTokenLifetime, Reason: "must be a string"})
+	default:
+		if _, err := time.ParseDuration(maxTokenLifetime.StringValue()); err != nil {
+			failures = append(failures, &pulumirpc.CheckFailure{Property: kind.SigningKeyRotationMaxTokenLifetime, Reason: err.Error()})
+		}
+	}
+	return failures
+}
+
+// diffRotation lists the changed inputs and the phase when the rotation moves
+// on, which is a change on every `pulumi up` until the desired generation is
+// stable. A rotation that cannot move on fails the diff
+func (p *provider) diffRotation(olds, news resource.PropertyMap) ([]string, error) {
+	var changed []string
+	for _, key := range rotationInputKeys {
+		if !olds[key].DeepEquals(news[key]) || news[key].ContainsUnknowns() {
+			changed = append(changed, string(key))
+		}
+	}
+	if news.ContainsUnknowns() {
+		sort.Strings(changed)
+		return changed, nil
+	}
+
+	previous, err := rotationState(olds)
+	if err != nil {
+		return nil, err
+	}
+	next, err := p.nextRotation(previous, news)
+	if err != nil {
+		return nil, err
+	}
+	if next != previous {
+		changed = append(changed, kind.SigningKeyRotationPhase)
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// createRotation starts stable at the desired generation
+func createRotation(inputs resource.PropertyMap) (resource.PropertyMap, error) {
+	signingKey, err := rotationSigningKey(inputs)
+	if err != nil {
+		return nil, err
+	}
+	return rotationOutputs(inputs, kind.InitialRotationState(signingKey)), nil
+}
+
+// updateRotation moves the rotation one phase forward
+func (p *provider) updateRotation(olds, news resource.PropertyMap) (resource.PropertyMap, error) {
+	previous, err := rotationState(olds)
+	if err != nil {
+		return nil, err
+	}
+	next, err := p.nextRotation(previous, news)
+	if err != nil {
+		return nil, err
+	}
+	return rotationOutputs(news, next), nil
+}
+
+func (p *provider) nextRotation(previous kind.RotationState, inputs resource.PropertyMap) (kind.RotationState, error) {
+	signingKey, err := rotationSigningKey(inputs)
+	if err != nil {
+		return previous, err
+	}
+	return kind.NextRotationState(previous, signingKey, p.now())
+}
+
+func rotationSigningKey(inputs resource.PropertyMap) (*kind.SigningKey, error) {
+	generation, ok := inputs[kind.SigningKeyRotationGeneration]
+	if !ok || !generation.IsNumber() {
+		return nil, errors.Errorf("property %q must be a number", kind.SigningKeyRotationGeneration)
+	}
+	maxTokenLifetime, ok := inputs[kind.SigningKeyRotationMaxTokenLifetime]
+	if !ok || !maxTokenLifetime.IsString() {
+		return nil, errors.Errorf("property %q must be a string", kind.SigningKeyRotationMaxTokenLifetime)
+	}
+	duration, err := time.ParseDuration(maxTokenLifetime.StringValue())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %q", kind.SigningKeyRotationMaxTokenLifetime)
+	}
+	return &kind.SigningKey{
+		Generation:       int(generation.NumberValue()),
+		MaxTokenLifetime: duration,
+	}, nil
+}
+
+func rotationState(outputs resource.PropertyMap) (kind.RotationState, error) {
+	generation, ok := outputs[kind.SigningKeyRotationSigningGeneration]
+	if !ok || !generation.IsNumber() {
+		return kind.RotationState{}, errors.Errorf("property %q must be a number", kind.SigningKeyRotationSigningGeneration)
+	}
+	phase, ok := outputs[kind.SigningKeyRotationPhase]
+	if !ok || !phase.IsString() {
+		return kind.RotationState{}, errors.Errorf("property %q must be a string", kind.SigningKeyRotationPhase)
+	}
+	state := kind.RotationState{
+		Generation: int(generation.NumberValue()),
+		Phase:      phase.StringValue(),
+	}
+	if switchedAt, ok := outputs[kind.SigningKeyRotationSwitchedAt]; ok && switchedAt.IsString() {
+		state.SwitchedAt = switchedAt.StringValue()
+	}
+	return state, nil
+}
+
+func rotationOutputs(inputs resource.PropertyMap, state kind.RotationState) resource.PropertyMap {
+	outputs := rotationInputs(inputs)
+	outputs[kind.SigningKeyRotationSigningGeneration] = resource.NewNumberProperty(float64(state.Generation))
+	outputs[kind.SigningKeyRotationPhase] = resource.NewStringProperty(state.Phase)
+	outputs[kind.SigningKeyRotationSwitchedAt] = resource.NewStringProperty(state.SwitchedAt)
+	return outputs
+}
+
+func rotationInputs(properties resource.PropertyMap) resource.PropertyMap {
+	inputs := resource.PropertyMap{}
+	for _, key := range rotationInputKeys {
+		if value, ok := properties[key]; ok {
+			inputs[key] = value
+		}
+	}
+	return inputs
+}
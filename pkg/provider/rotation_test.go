@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/cluster/kind"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+const testRotationURN = "urn:pulumi:dev::irsa-anywhere::irsa:cluster:kind-aws$irsa:index:SigningKeyRotation::kind-aws-service-account-rotation"
+
+func rotationTestInputs(generation int) resource.PropertyMap {
+	return resource.PropertyMap{
+		kind.SigningKeyRotationGeneration:       resource.NewNumberProperty(float64(generation)),
+		kind.SigningKeyRotationMaxTokenLifetime: resource.NewStringProperty("24h"),
+	}
+}
+
+func TestCheckRotation(t *testing.T) {
+	p := newProvider("0.0.1")
+	inputs := resource.PropertyMap{
+		kind.SigningKeyRotationGeneration:       resource.NewNumberProperty(1.5),
+		kind.SigningKeyRotationMaxTokenLifetime: resource.NewStringProperty("a day"),
+	}
+	checked, err := p.Check(context.Background(), &pulumirpc.CheckRequest{Urn: testRotationURN, News: mustMarshal(t, inputs)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checked.GetFailures()) != 2 {
+		t.Errorf("expected the generation and the max token lifetime to be rejected, got: %v", checked.GetFailures())
+	}
+}
+
+func TestRotationMovesOnePhasePerUpdate(t *testing.T) {
+	p := newProvider("0.0.1")
+	now := time.Date(2021, 11, 5, 9, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	created, err := p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: testRotationURN, Properties: mustMarshal(t, rotationTestInputs(0))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := created.GetProperties()
+
+	diff, err := p.Diff(context.Background(), &pulumirpc.DiffRequest{Urn: testRotationURN, Olds: state, News: mustMarshal(t, rotationTestInputs(0))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.GetChanges() != pulumirpc.DiffResponse_DIFF_NONE {
+		t.Errorf("expected a stable rotation to have no changes, got: %v", diff.GetDiffs())
+	}
+
+	expectedPhases := []kind.RotationState{
+		{Generation: 0, Phase: kind.RotationPhaseOverlap},
+		{Generation: 1, Phase: kind.RotationPhaseSwitched, SwitchedAt: "2021-11-05T09:00:00Z"},
+		// the previous key is published until the max token lifetime passed
+		{Generation: 1, Phase: kind.RotationPhaseSwitched, SwitchedAt: "2021-11-05T09:00:00Z"},
+		{Generation: 1, Phase: kind.RotationPhaseStable},
+	}
+	for i, expected := range expectedPhases {
+		if i == len(expectedPhases)-1 {
+			now = now.Add(24 * time.Hour)
+		}
+		news := mustMarshal(t, rotationTestInputs(1))
+		diff, err := p.Diff(context.Background(), &pulumirpc.DiffRequest{Urn: testRotationURN, Olds: state, News: news})
+		if err != nil {
+			t.Fatal(err)
+		}
+		waiting := i == 2
+		if unchanged := diff.GetChanges() == pulumirpc.DiffResponse_DIFF_NONE; unchanged != waiting {
+			t.Errorf("%+v unexpected diff: %v", expected, diff.GetDiffs())
+		}
+
+		updated, err := p.Update(context.Background(), &pulumirpc.UpdateRequest{Urn: testRotationURN, Olds: state, News: news})
+		if err != nil {
+			t.Fatal(err)
+		}
+		state = updated.GetProperties()
+		outputs, err := unmarshalProperties(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual, err := rotationState(outputs); err != nil {
+			t.Error(err)
+		} else if actual != expected {
+			t.Errorf("expected: %+v\n, got: %+v\n", expected, actual)
+		}
+	}
+
+	// the key of generation 1 is no longer declared once 3 is desired
+	if _, err := p.Diff(context.Background(), &pulumirpc.DiffRequest{Urn: testRotationURN, Olds: state, News: mustMarshal(t, rotationTestInputs(3))}); err == nil {
+		t.Error("expected rotating more than one generation to fail the diff")
+	}
+}
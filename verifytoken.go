@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/frezbo/irsa-anywhere/pkg/cluster"
+	"github.com/frezbo/irsa-anywhere/pkg/oidc"
+	"github.com/pkg/errors"
+)
+
+const verifyTokenCommand = "verify-token"
+
+// verifyToken checks a projected service account token against the published
+// issuer documents the same way AWS STS does, naming every wrong claim
+func verifyToken(args []string) error {
+	flags := flag.NewFlagSet(verifyTokenCommand, flag.ContinueOnError)
+	issuerURL := flags.String("issuer", "", "issuer url to fetch the published documents from, e.g. https://<bucket>.s3.<region>.amazonaws.com")
+	discoveryFile := flags.String("discovery", "", "read the discovery document from a file instead of fetching it, requires -jwks")
+	jwksFile := flags.String("jwks", "", fmt.Sprintf("read %s from a file instead of fetching it, requires -discovery", oidc.KeysJSON))
+	tokenFile := flags.String("token", "-", "file holding the token, - reads it from stdin")
+	audience := flags.String("audience", cluster.STSAudience, "expected audience")
+	subject := flags.String("subject", "", "expected subject, system:serviceaccount:<namespace>:<name>, any service account when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	documents, err := issuerDocuments(*issuerURL, *discoveryFile, *jwksFile)
+	if err != nil {
+		return err
+	}
+	// the issuer the token was issued for is the one AWS trusts
+	expectedIssuer := *issuerURL
+	if expectedIssuer == "" {
+		expectedIssuer = documents.Discovery.Issuer
+	}
+
+	token, err := readToken(*tokenFile)
+	if err != nil {
+		return err
+	}
+
+	claims, err := oidc.VerifyToken(token, documents.JWKS, oidc.TokenExpectations{
+		Issuer:   strings.TrimSuffix(expectedIssuer, "/"),
+		Audience: *audience,
+		Subject:  *subject,
+	})
+	if claimErrors, ok := err.(oidc.ClaimErrors); ok {
+		for _, claimError := range claimErrors {
+			fmt.Fprintln(os.Stderr, claimError)
+		}
+		return errors.New("token signature is valid but its claims are not")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("token is valid\n  sub: %s\n  aud: %s\n  exp: %s\n", claims.Subject, strings.Join(claims.Audience, ","), time.Unix(claims.Expiry, 0).UTC().Format(time.RFC3339))
+	return nil
+}
+
+func issuerDocuments(issuerURL, discoveryFile, jwksFile string) (*oidc.Documents, error) {
+	switch {
+	case discoveryFile != "" && jwksFile != "":
+		discoveryJSON, err := os.ReadFile(discoveryFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the discovery document")
+		}
+		jwksJSON, err := os.ReadFile(jwksFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the JWKS")
+		}
+		return oidc.ParseDocuments(discoveryJSON, jwksJSON)
+	case discoveryFile != "" || jwksFile != "":
+		return nil, errors.New("-discovery and -jwks must be set together")
+	case issuerURL != "":
+		return oidc.FetchDocuments(&http.Client{Timeout: 30 * time.Second}, issuerURL)
+	default:
+		return nil, errors.New("either -issuer or -discovery and -jwks must be set")
+	}
+}
+
+func readToken(tokenFile string) (string, error) {
+	var token []byte
+	var err error
+	if tokenFile == "-" {
+		token, err = io.ReadAll(os.Stdin)
+	} else {
+		token, err = os.ReadFile(tokenFile)
+	}
+	return strings.TrimSpace(string(token)), errors.Wrap(err, "failed to read the token")
+}